@@ -1,14 +1,18 @@
 package vue
 
 import (
+	"fmt"
 	"reflect"
+	"strings"
 )
 
 // bus contains subscriptions of events to methods.
+// The buses of the components of an app share the subscriptions to topics of the app.
 type bus struct {
 	parent *bus
 	caller caller
 	subs   map[string]map[string]struct{}
+	app    *appBus
 }
 
 // caller calls a method with optional arguments.
@@ -17,9 +21,14 @@ type caller interface {
 }
 
 // newBus creates a new event bus.
+// The bus of the root component creates the subscriptions to topics of the app.
 func newBus(parent *bus, caller caller) *bus {
 	subs := make(map[string]map[string]struct{}, 0)
-	return &bus{parent: parent, caller: caller, subs: subs}
+	app := &appBus{}
+	if parent != nil {
+		app = parent.app
+	}
+	return &bus{parent: parent, caller: caller, subs: subs, app: app}
 }
 
 // pub publishes the event with the method and optional arguments.
@@ -59,3 +68,146 @@ func (bus *bus) sub(event, method string) {
 		bus.subs[event] = map[string]struct{}{method: {}}
 	}
 }
+
+// Bus is the event bus of an app, it signals between components which are not related.
+// Methods of components are subscribed to topics, then called with the payloads published to the topics.
+// Topics are separated by dots, e.g. auth.changed. Patterns of subscriptions may contain wildcards:
+// a * segment matches any segment, a trailing * segment matches any remaining segments.
+// For example: ws.* matches ws.message and ws.message.text, * matches all topics.
+// Subscriptions are removed when their component is released.
+type Bus struct {
+	vm   *ViewModel
+	loop bool
+}
+
+// appBus contains the subscriptions of the view models of an app to topics.
+type appBus struct {
+	subs []*subscription
+}
+
+// subscription subscribes the method of the view model to the topic pattern.
+type subscription struct {
+	pattern string
+	method  string
+	once    bool
+	vm      *ViewModel
+}
+
+// On subscribes the method of the component to the topic pattern.
+// The method is called with the payload of each publication, e.g. func(vctx Context, user User).
+// Payloads which are not assignable to the parameters of the method are passed to the error handler.
+func (b *Bus) On(pattern, method string) {
+	b.subscribe(pattern, method, false)
+}
+
+// Once subscribes the method of the component to the next publication to the topic pattern.
+func (b *Bus) Once(pattern, method string) {
+	b.subscribe(pattern, method, true)
+}
+
+// Off unsubscribes the method of the component from the topic pattern.
+func (b *Bus) Off(pattern, method string) {
+	vm := b.vm
+	if !b.loop {
+		defer vm.sched.enter()()
+	}
+	vm.bus.app.unsubscribe(func(sub *subscription) bool {
+		return sub.vm == vm && sub.pattern == pattern && sub.method == method
+	})
+}
+
+// Pub publishes the payload to the topic.
+// Methods subscribed to patterns matching the topic are called in the order of their subscriptions.
+func (b *Bus) Pub(topic string, payload ...interface{}) {
+	vm := b.vm
+	if !b.loop {
+		defer vm.sched.enter()()
+	}
+	vm.bus.app.pub(topic, payload)
+}
+
+// subscribe subscribes the method of the component, the subscription is removed once called if once.
+// Unknown methods are passed to the error handler.
+func (b *Bus) subscribe(pattern, method string, once bool) {
+	vm := b.vm
+	if !b.loop {
+		defer vm.sched.enter()()
+		defer vm.catch()
+	}
+	if _, ok := vm.comp.methods[method]; !ok {
+		panic(fmt.Errorf("vue: unknown method: %s", method))
+	}
+	if vm.released {
+		return
+	}
+	app := vm.bus.app
+	for _, sub := range app.subs {
+		if sub.vm == vm && sub.pattern == pattern && sub.method == method {
+			sub.once = once
+			return
+		}
+	}
+	app.subs = append(app.subs, &subscription{pattern: pattern, method: method, once: once, vm: vm})
+}
+
+// pub calls the methods subscribed to patterns matching the topic with the payload.
+// Subscriptions made while publishing are not called until the next publication.
+// Errors are passed to the error handlers of the subscribed components.
+func (app *appBus) pub(topic string, payload []interface{}) {
+	var matched []*subscription
+	for _, sub := range app.subs {
+		if matchTopic(sub.pattern, topic) {
+			matched = append(matched, sub)
+		}
+	}
+	app.unsubscribe(func(sub *subscription) bool {
+		return sub.once && matchTopic(sub.pattern, topic)
+	})
+
+	for _, sub := range matched {
+		if !sub.vm.released {
+			sub.call(topic, payload)
+		}
+	}
+}
+
+// call calls the method of the subscription with the payload of the topic.
+func (sub *subscription) call(topic string, payload []interface{}) {
+	vm := sub.vm
+	defer vm.catch()
+	if _, err := (&env{vm: vm, handler: true}).Call(sub.method, payload); err != nil {
+		panic(fmt.Errorf("vue: topic %s: %w", topic, err))
+	}
+}
+
+// unsubscribe removes the subscriptions which match.
+func (app *appBus) unsubscribe(match func(sub *subscription) bool) {
+	subs := app.subs[:0]
+	for _, sub := range app.subs {
+		if !match(sub) {
+			subs = append(subs, sub)
+		}
+	}
+	for i := len(subs); i < len(app.subs); i++ {
+		app.subs[i] = nil
+	}
+	app.subs = subs
+}
+
+// matchTopic returns true if the pattern matches the topic.
+// For example: auth.* matches auth.changed, *.changed matches auth.changed but not auth.user.changed.
+func matchTopic(pattern, topic string) bool {
+	patterns, topics := strings.Split(pattern, "."), strings.Split(topic, ".")
+	for i, p := range patterns {
+		if i == len(topics) {
+			return false
+		}
+		if p == "*" && i == len(patterns)-1 {
+			return true
+		}
+		if p != "*" && p != topics[i] {
+			return false
+		}
+	}
+	return len(patterns) == len(topics)
+}
@@ -0,0 +1,475 @@
+package vue
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+
+	"github.com/tigerbot/vue/expr"
+	"github.com/tigerbot/vue/mapper"
+)
+
+// Diagnostics is the list of errors found by validating a component.
+// For example: []error{*FieldError, *TemplateError}
+type Diagnostics []error
+
+func (diags Diagnostics) Error() string {
+	msgs := make([]string, 0, len(diags))
+	for _, err := range diags {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// As finds the first error of the diagnostics which matches the target, see errors.As.
+func (diags Diagnostics) As(target interface{}) bool {
+	for _, err := range diags {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// Type is the type of a field for checking templates without values,
+// e.g. from reflection or from the static analysis of sources.
+// Kinds of pointers are the kinds of their elements.
+type Type interface {
+	Kind() reflect.Kind
+	// Elem returns the type of the items of slices, arrays, maps and channels.
+	Elem() Type
+	// Field returns the type of the field at the path, e.g. Todos[0].Text or Tags["key"].
+	// The type may be nil if the field is known, but not its type.
+	Field(path string) (Type, bool)
+}
+
+// Schema describes the fields and methods a template of a component may reference.
+// Types may be nil if not known, e.g. any field of the data is known.
+type Schema struct {
+	Data     Type
+	Props    []string
+	Computed map[string]Type
+	Methods  []string
+	// Subs maps the elements of subcomponents to their props.
+	// The props of a subcomponent are nil if not known, then its props are not checked.
+	Subs map[string][]string
+}
+
+// CheckTemplate compiles the template then checks every field and method referenced by
+// the expressions of text and vue attributes against the schema, e.g. to lint templates ahead of rendering.
+func CheckTemplate(tmpl string, schema *Schema) Diagnostics {
+	prog, err := compile(tmpl)
+	if err != nil {
+		return Diagnostics{err}
+	}
+	return schema.check(prog)
+}
+
+// typeScope maps the item names of loops to their types.
+// A nil type is not known ahead of rendering, e.g. props.
+type typeScope map[string]Type
+
+// checker checks the references of a template against the schema.
+type checker struct {
+	schema *Schema
+	prog   *program
+	diags  Diagnostics
+}
+
+// check checks the compiled template of the component against its schema.
+func (comp *Comp) check() Diagnostics {
+	return comp.schema().check(comp.prog)
+}
+
+// check walks the compiled template and checks the references against the schema.
+func (schema *Schema) check(prog *program) Diagnostics {
+	c := &checker{schema: schema, prog: prog}
+	for child := prog.root.FirstChild; child != nil; child = child.NextSibling {
+		c.checkNode(child, typeScope{})
+	}
+	return c.diags
+}
+
+// schema returns the schema of the component from reflection.
+func (comp *Comp) schema() *Schema {
+	schema := &Schema{
+		Data:     reflectType{comp.dataType()},
+		Computed: make(map[string]Type, len(comp.computed)),
+		Subs:     make(map[string][]string, len(comp.subs)),
+	}
+	for prop := range comp.props {
+		schema.Props = append(schema.Props, prop)
+	}
+	for name, fn := range comp.computed {
+		schema.Computed[name] = reflectType{fn.Type().Out(0)}
+	}
+	for name := range comp.methods {
+		schema.Methods = append(schema.Methods, name)
+	}
+	for element, sub := range comp.subs {
+		props := make([]string, 0, len(sub.props))
+		for prop := range sub.props {
+			props = append(props, prop)
+		}
+		schema.Subs[element] = props
+	}
+	return schema
+}
+
+// dataType returns the type of the data, or the return type of the data function.
+func (comp *Comp) dataType() reflect.Type {
+	typ := reflect.TypeOf(comp.data)
+	if typ.Kind() == reflect.Func {
+		return typ.Out(0)
+	}
+	return typ
+}
+
+// reflectType is the type of a field from reflection.
+type reflectType struct {
+	typ reflect.Type
+}
+
+func (rt reflectType) Kind() reflect.Kind {
+	return rt.elem().Kind()
+}
+
+func (rt reflectType) Elem() Type {
+	return reflectType{rt.elem().Elem()}
+}
+
+func (rt reflectType) Field(path string) (Type, bool) {
+	if typ, ok := mapper.GetFieldType(rt.typ, path); ok {
+		return reflectType{typ}, true
+	}
+	return nil, false
+}
+
+// elem returns the element type of pointers.
+func (rt reflectType) elem() reflect.Type {
+	if rt.typ.Kind() == reflect.Ptr {
+		return rt.typ.Elem()
+	}
+	return rt.typ
+}
+
+// checkNode recursively checks the html node within the scope of loops.
+func (c *checker) checkNode(node *html.Node, sc typeScope) {
+	switch node.Type {
+	case html.TextNode:
+		c.checkText(node, sc)
+		return
+	case html.ElementNode:
+	default:
+		return
+	}
+
+	for _, attr := range node.Attr {
+		if strings.HasPrefix(attr.Key, v) {
+			sc = c.checkAttr(node, attr, sc)
+		}
+	}
+
+	// The children of subcomponent elements are not rendered.
+	if _, ok := c.schema.Subs[node.Data]; ok {
+		return
+	}
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		c.checkNode(child, sc)
+	}
+}
+
+// checkText checks the expressions of the text node.
+func (c *checker) checkText(node *html.Node, sc typeScope) {
+	text, err := c.prog.text(node.Data)
+	if err != nil || text == nil {
+		return
+	}
+	for _, x := range text.exprs {
+		c.checkExpr(node.Parent, "", x, sc)
+	}
+}
+
+// checkAttr checks the vue attribute of the element.
+// The scope of the element and its children is returned, e.g. with the item of a loop.
+func (c *checker) checkAttr(node *html.Node, attr html.Attribute, sc typeScope) typeScope {
+	typ, arg := splitAttr(attr.Key)
+	src := attr.Val
+	var names []string
+	switch typ {
+	case vElse:
+		return sc
+	case vFor:
+		loop, err := parseFor(attr.Val)
+		if err != nil {
+			return sc
+		}
+		names, src = loop.names, loop.src
+	}
+	x, err := c.prog.expr(src)
+	if err != nil {
+		return sc
+	}
+
+	switch typ {
+	case vBind:
+		c.checkExpr(node, attr.Key, x, sc)
+		if arg != "" {
+			c.checkProp(node, attr.Key, arg)
+		}
+	case vIf, vElseIf, vShow:
+		c.checkExpr(node, attr.Key, x, sc)
+	case vHtml:
+		if t, ok := c.checkExpr(node, attr.Key, x, sc); ok && !isKind(t, reflect.String) {
+			c.report(node, attr.Key, src, errNotString)
+		}
+	case vModel:
+		t, ok := c.checkExpr(node, attr.Key, x, sc)
+		if _, isSub := c.schema.Subs[node.Data]; isSub {
+			// Subcomponents receive the value of any type as a prop.
+			prop, _ := splitModel(arg)
+			c.checkProp(node, attr.Key, prop)
+		} else if ok && !isModelType(t, nodeModelKind(node)) {
+			c.report(node, attr.Key, src, errModelType)
+		} else if _, modifiers := splitModel(arg); ok && !isNumberModel(t, nodeModelKind(node), modifiers) {
+			c.report(node, attr.Key, src, errNumberType)
+		}
+	case vFor:
+		t, ok := c.checkExpr(node, attr.Key, x, sc)
+		if !ok {
+			return sc
+		}
+		// The types of the item, then the key or index, then the index.
+		types := []Type{nil, nil, kindType(reflect.Int)}
+		if t != nil {
+			switch kind := t.Kind(); {
+			case kind == reflect.Slice, kind == reflect.Array, kind == reflect.Chan:
+				types[0], types[1] = t.Elem(), kindType(reflect.Int)
+			case kind == reflect.Map:
+				types[0] = t.Elem()
+			case kind == reflect.Struct:
+				types[1] = kindType(reflect.String)
+			case reflect.Int <= kind && kind <= reflect.Int64:
+				types[0], types[1] = kindType(reflect.Int), kindType(reflect.Int)
+			case kind == reflect.Interface:
+			default:
+				c.report(node, attr.Key, src, errNotIterable)
+				return sc
+			}
+		}
+		loop := make(typeScope, len(sc)+len(names))
+		for k, v := range sc {
+			loop[k] = v
+		}
+		for i, name := range names {
+			loop[name] = types[i]
+		}
+		return loop
+	case vOn:
+		// Handlers of a name call the method, other handlers are expressions.
+		if field, ok := x.(*expr.Field); ok && len(field.Accessors) == 0 {
+			c.checkMethod(node, attr.Key, field.Name)
+		} else {
+			handler := make(typeScope, len(sc)+1)
+			for k, v := range sc {
+				handler[k] = v
+			}
+			handler[eventField] = nil
+			c.checkExpr(node, attr.Key, x, handler)
+		}
+	}
+	return sc
+}
+
+// checkExpr recursively checks the fields and methods of the expression, then returns its type.
+// The type is nil if not known, e.g. the results of methods.
+func (c *checker) checkExpr(node *html.Node, attr string, x expr.Expr, sc typeScope) (Type, bool) {
+	switch x := x.(type) {
+	case *expr.Literal:
+		switch x.Value.(type) {
+		case string:
+			return kindType(reflect.String), true
+		case bool:
+			return kindType(reflect.Bool), true
+		}
+		return nil, true
+	case *expr.Field:
+		return c.checkField(node, attr, x, sc)
+	case *expr.Unary:
+		t, ok := c.checkExpr(node, attr, x.X, sc)
+		if x.Op == "!" {
+			return kindType(reflect.Bool), ok
+		}
+		return t, ok
+	case *expr.Binary:
+		lt, lok := c.checkExpr(node, attr, x.X, sc)
+		rt, rok := c.checkExpr(node, attr, x.Y, sc)
+		switch x.Op {
+		case "+":
+			if lt != nil && lt.Kind() == reflect.String || rt != nil && rt.Kind() == reflect.String {
+				return kindType(reflect.String), lok && rok
+			}
+			return nil, lok && rok
+		case "-", "*", "/", "%":
+			return nil, lok && rok
+		default:
+			return kindType(reflect.Bool), lok && rok
+		}
+	case *expr.Call:
+		ok := c.checkMethod(node, attr, x.Name)
+		for _, arg := range x.Args {
+			if _, argOk := c.checkExpr(node, attr, arg, sc); !argOk {
+				ok = false
+			}
+		}
+		return nil, ok
+	default:
+		return nil, false
+	}
+}
+
+// checkMethod checks that the method is known.
+func (c *checker) checkMethod(node *html.Node, attr, method string) bool {
+	if containsString(c.schema.Methods, method) {
+		return true
+	}
+	err := fmt.Errorf("unknown method: %s", method)
+	c.diags = append(c.diags, &TemplateError{Path: location(node), Attr: attr, Err: err})
+	return false
+}
+
+// checkProp checks that the subcomponent of the element declares the bound prop.
+// The key, class and style are bound to the root element of the subcomponent instead.
+func (c *checker) checkProp(node *html.Node, attr, key string) {
+	props, ok := c.schema.Subs[node.Data]
+	if !ok || props == nil || key == keyAttr || key == "class" || key == "style" {
+		return
+	}
+	if prop := strings.Title(key); !containsString(props, prop) {
+		err := fmt.Errorf("undeclared prop %s of subcomponent %s", prop, node.Data)
+		c.diags = append(c.diags, &TemplateError{Path: location(node), Attr: attr, Err: err})
+	}
+}
+
+// checkField checks that the field and the expressions of its indexes are known, then returns its type.
+// The type is nil if the field is known, but not its type.
+func (c *checker) checkField(node *html.Node, attr string, field *expr.Field, sc typeScope) (Type, bool) {
+	path := field.Name
+	for _, acc := range field.Accessors {
+		if acc.Index == nil {
+			path += "." + acc.Name
+			continue
+		}
+		if lit, ok := acc.Index.(*expr.Literal); ok {
+			switch key := lit.Value.(type) {
+			case int:
+				path += "[" + strconv.Itoa(key) + "]"
+				continue
+			case string:
+				path += "[" + strconv.Quote(key) + "]"
+				continue
+			}
+		}
+
+		// The index is only known with a value, so any index of the slice, array or map is checked.
+		c.checkExpr(node, attr, acc.Index, sc)
+		if t, ok := c.resolve(path, sc); ok && t != nil && t.Kind() == reflect.Map {
+			path += `[""]`
+		} else {
+			path += "[0]"
+		}
+	}
+
+	t, ok := c.resolve(path, sc)
+	if !ok {
+		c.report(node, attr, field.String(), errUnknownField)
+	}
+	return t, ok
+}
+
+// resolve resolves the type of the field from the scope, data, props and computed.
+func (c *checker) resolve(field string, sc typeScope) (Type, bool) {
+	topLevel, subPath := splitField(field)
+	if item, ok := sc[topLevel]; ok {
+		if item == nil {
+			return nil, true
+		}
+		return item.Field(subPath)
+	}
+	if c.schema.Data != nil {
+		if t, ok := c.schema.Data.Field(field); ok {
+			return t, true
+		}
+	}
+	if containsString(c.schema.Props, topLevel) {
+		return nil, true
+	}
+	if t, ok := c.schema.Computed[topLevel]; ok {
+		if t == nil {
+			return nil, true
+		}
+		return t.Field(subPath)
+	}
+	return nil, false
+}
+
+// report appends a field error with the location of the node.
+func (c *checker) report(node *html.Node, attr, field string, err error) {
+	c.diags = append(c.diags, &FieldError{Field: field, Path: location(node), Attr: attr, Err: err})
+}
+
+// isModelType returns true if the type is unknown or supported by the model of the element kind.
+// Checkboxes support bools and slices, multiple select elements support slices,
+// other elements support values of basic kinds or structs, e.g. implementing encoding.TextUnmarshaler.
+func isModelType(t Type, kind modelKind) bool {
+	if t == nil || t.Kind() == reflect.Interface {
+		return true
+	}
+	switch k := t.Kind(); kind {
+	case modelCheckbox:
+		return k == reflect.Bool || k == reflect.Slice
+	case modelSelectMultiple:
+		return k == reflect.Slice
+	case modelText:
+		return k == reflect.String || k == reflect.Struct || isNumeric(k)
+	default:
+		return k == reflect.String || k == reflect.Struct || k == reflect.Bool || isNumeric(k)
+	}
+}
+
+// isNumberModel returns true if the type is unknown or a number, unless the number modifier is one of the modifiers.
+// Checkboxes and multiple select elements of slices parse numbers into their items.
+func isNumberModel(t Type, kind modelKind, modifiers string) bool {
+	if _, ok := modSet(strings.TrimPrefix(modifiers, "."))["Number"]; !ok {
+		return true
+	}
+	if t != nil && t.Kind() == reflect.Slice && (kind == modelCheckbox || kind == modelSelectMultiple) {
+		t = t.Elem()
+	}
+	return t == nil || t.Kind() == reflect.Interface || isNumeric(t.Kind())
+}
+
+// kindType is the type of the results of expressions, only the kind is known.
+type kindType reflect.Kind
+
+func (kt kindType) Kind() reflect.Kind {
+	return reflect.Kind(kt)
+}
+
+func (kt kindType) Elem() Type {
+	return nil
+}
+
+func (kt kindType) Field(path string) (Type, bool) {
+	return kt, path == ""
+}
+
+// isKind returns true if the type is unknown or of the kind.
+// Interfaces are only known with a value.
+func isKind(t Type, kind reflect.Kind) bool {
+	return t == nil || t.Kind() == kind || t.Kind() == reflect.Interface
+}
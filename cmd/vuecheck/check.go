@@ -0,0 +1,297 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/constant"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path/filepath"
+
+	"github.com/tigerbot/vue"
+)
+
+// vuePath is the import path of the vue package.
+const vuePath = "github.com/tigerbot/vue"
+
+// diagnostic is an error of a template at its position within the template, see templatePos.
+type diagnostic struct {
+	pos token.Position
+	err error
+}
+
+func (diag diagnostic) String() string {
+	return fmt.Sprintf("%s: %v", diag.pos, diag.err)
+}
+
+// pkg is a type-checked package.
+type pkg struct {
+	fset  *token.FileSet
+	files []*ast.File
+	info  *types.Info
+	// comps maps the variables assigned by Component to their calls.
+	comps map[types.Object]*ast.CallExpr
+}
+
+// checkDir type-checks the package of the directory, then checks the templates of its components.
+func checkDir(dir string) ([]diagnostic, error) {
+	p, err := loadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var diags []diagnostic
+	for _, file := range p.files {
+		ast.Inspect(file, func(node ast.Node) bool {
+			call, ok := node.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			if name := p.vueFunc(call); name == "Component" || name == "New" {
+				diags = append(diags, p.checkComponent(call)...)
+			}
+			return true
+		})
+	}
+	return diags, nil
+}
+
+// loadDir parses and type-checks the package of the directory from source.
+func loadDir(dir string) (*pkg, error) {
+	bp, err := build.ImportDir(dir, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &pkg{
+		fset: token.NewFileSet(),
+		info: &types.Info{
+			Types: make(map[ast.Expr]types.TypeAndValue),
+			Defs:  make(map[*ast.Ident]types.Object),
+			Uses:  make(map[*ast.Ident]types.Object),
+		},
+		comps: make(map[types.Object]*ast.CallExpr),
+	}
+	for _, name := range bp.GoFiles {
+		file, err := parser.ParseFile(p.fset, filepath.Join(dir, name), nil, 0)
+		if err != nil {
+			return nil, err
+		}
+		p.files = append(p.files, file)
+	}
+
+	conf := &types.Config{Importer: importer.ForCompiler(p.fset, "source", nil)}
+	if _, err := conf.Check(bp.ImportPath, p.fset, p.files, p.info); err != nil {
+		return nil, err
+	}
+	p.findComponents()
+	return p, nil
+}
+
+// findComponents finds the variables assigned by Component, e.g. to be used as subcomponents.
+func (p *pkg) findComponents() {
+	for _, file := range p.files {
+		ast.Inspect(file, func(node ast.Node) bool {
+			var lhs []*ast.Ident
+			var rhs []ast.Expr
+			switch node := node.(type) {
+			case *ast.AssignStmt:
+				for _, expr := range node.Lhs {
+					ident, _ := expr.(*ast.Ident)
+					lhs = append(lhs, ident)
+				}
+				rhs = node.Rhs
+			case *ast.ValueSpec:
+				lhs, rhs = node.Names, node.Values
+			default:
+				return true
+			}
+			if len(lhs) == 0 || len(rhs) != 1 || lhs[0] == nil {
+				return true
+			}
+			if call, ok := rhs[0].(*ast.CallExpr); ok && p.vueFunc(call) == "Component" {
+				if obj := p.object(lhs[0]); obj != nil {
+					p.comps[obj] = call
+				}
+			}
+			return true
+		})
+	}
+}
+
+// object returns the object defined or used by the identifier.
+func (p *pkg) object(ident *ast.Ident) types.Object {
+	if obj, ok := p.info.Defs[ident]; ok && obj != nil {
+		return obj
+	}
+	return p.info.Uses[ident]
+}
+
+// vueFunc returns the name of the vue function called, otherwise empty.
+func (p *pkg) vueFunc(call *ast.CallExpr) string {
+	var ident *ast.Ident
+	switch fun := call.Fun.(type) {
+	case *ast.SelectorExpr:
+		ident = fun.Sel
+	case *ast.Ident:
+		ident = fun
+	default:
+		return ""
+	}
+	fn, ok := p.info.Uses[ident].(*types.Func)
+	if !ok || fn.Pkg() == nil || fn.Pkg().Path() != vuePath {
+		return ""
+	}
+	return fn.Name()
+}
+
+// component is the template and the schema of a component from its options.
+type component struct {
+	tmpl    string
+	tmplPos token.Pos
+	tmplLit ast.Expr
+	schema  *vue.Schema
+}
+
+// checkComponent checks the template of the component created by the call.
+// Components without a constant template are not checked.
+func (p *pkg) checkComponent(call *ast.CallExpr) []diagnostic {
+	comp := p.component(call)
+	if comp.tmplPos == token.NoPos {
+		return nil
+	}
+
+	var diags []diagnostic
+	for _, err := range vue.CheckTemplate(comp.tmpl, comp.schema) {
+		diags = append(diags, diagnostic{pos: p.templatePos(comp, err), err: err})
+	}
+	return diags
+}
+
+// component collects the template and the schema from the options of the call.
+// Only options which are vue calls are collected, options from variables are not known.
+func (p *pkg) component(call *ast.CallExpr) *component {
+	comp := &component{
+		schema: &vue.Schema{
+			Data:     goType{types.NewStruct(nil, nil)},
+			Computed: make(map[string]vue.Type),
+			Subs:     make(map[string][]string),
+		},
+	}
+	for _, arg := range call.Args {
+		option, ok := arg.(*ast.CallExpr)
+		if !ok || len(option.Args) == 0 {
+			continue
+		}
+		args := option.Args
+		switch p.vueFunc(option) {
+		case "Template":
+			if tmpl, ok := p.constString(args[0]); ok {
+				comp.tmpl, comp.tmplPos, comp.tmplLit = tmpl, option.Pos(), args[0]
+			}
+		case "Data":
+			typ := p.info.TypeOf(args[0])
+			if sig, ok := typ.Underlying().(*types.Signature); ok && sig.Results().Len() == 1 {
+				typ = sig.Results().At(0).Type()
+			}
+			comp.schema.Data = goType{typ}
+		case "Props":
+			for _, arg := range args {
+				if prop, ok := p.constString(arg); ok {
+					comp.schema.Props = append(comp.schema.Props, prop)
+				}
+			}
+		case "PropsOf":
+			comp.schema.Props = append(comp.schema.Props, structFields(p.info.TypeOf(args[0]))...)
+		case "Method":
+			if name, ok := p.constString(args[0]); ok {
+				comp.schema.Methods = append(comp.schema.Methods, name)
+			}
+		case "Methods":
+			for _, arg := range args {
+				comp.schema.Methods = append(comp.schema.Methods, funcName(arg))
+			}
+		case "Computed":
+			if name, ok := p.constString(args[0]); ok && len(args) > 1 {
+				comp.schema.Computed[name] = p.resultType(args[1])
+			}
+		case "Computeds":
+			for _, arg := range args {
+				comp.schema.Computed[funcName(arg)] = p.resultType(arg)
+			}
+		case "Sub":
+			if element, ok := p.constString(args[0]); ok && len(args) > 1 {
+				// The props of subcomponents which are not resolved are nil, so they are not checked.
+				props, _ := p.subProps(args[1])
+				comp.schema.Subs[element] = props
+			}
+		}
+	}
+	return comp
+}
+
+// subProps returns the props of the subcomponent, e.g. from the variable assigned by Component.
+// Returns false if the subcomponent is not resolved, e.g. created by a function or imported from another package.
+func (p *pkg) subProps(expr ast.Expr) ([]string, bool) {
+	call, ok := expr.(*ast.CallExpr)
+	if ident, isIdent := expr.(*ast.Ident); isIdent {
+		call, ok = p.comps[p.object(ident)]
+	}
+	if !ok || p.vueFunc(call) != "Component" {
+		return nil, false
+	}
+	return append([]string{}, p.component(call).schema.Props...), true
+}
+
+// structFields returns the exported fields of the struct, or of the struct pointed to, e.g. the props of PropsOf.
+func structFields(typ types.Type) []string {
+	if ptr, ok := typ.Underlying().(*types.Pointer); ok {
+		typ = ptr.Elem()
+	}
+	st, ok := typ.Underlying().(*types.Struct)
+	if !ok {
+		return nil
+	}
+	var fields []string
+	for i := 0; i < st.NumFields(); i++ {
+		if field := st.Field(i); field.Exported() {
+			fields = append(fields, field.Name())
+		}
+	}
+	return fields
+}
+
+// constString returns the value of the constant string expression.
+func (p *pkg) constString(expr ast.Expr) (string, bool) {
+	tv, ok := p.info.Types[expr]
+	if !ok || tv.Value == nil || tv.Value.Kind() != constant.String {
+		return "", false
+	}
+	return constant.StringVal(tv.Value), true
+}
+
+// resultType returns the type of the first result of the function expression.
+// The type is nil if not known.
+func (p *pkg) resultType(expr ast.Expr) vue.Type {
+	sig, ok := p.info.TypeOf(expr).Underlying().(*types.Signature)
+	if !ok || sig.Results().Len() == 0 {
+		return nil
+	}
+	return goType{sig.Results().At(0).Type()}
+}
+
+// funcName returns the name of the function expression, as registered by vue.
+// For example: Reverse or data.Reverse -> Reverse
+func funcName(expr ast.Expr) string {
+	switch expr := expr.(type) {
+	case *ast.Ident:
+		return expr.Name
+	case *ast.SelectorExpr:
+		return expr.Sel.Name
+	default:
+		return ""
+	}
+}
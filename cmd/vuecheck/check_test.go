@@ -0,0 +1,55 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tigerbot/vue"
+)
+
+func TestCheckDir(t *testing.T) {
+	diags, err := checkDir("testdata/todo")
+	if err != nil {
+		t.Fatalf("failed to check: %v", err)
+	}
+
+	// The diagnostics are reported at their positions within the template literal.
+	want := []string{
+		"main.go:57:30: vue: field Mesage at div > p: unknown data field",
+		"main.go:58:10: vue: field Seen at div > input v-model: data field type is not supported by the model of the element",
+		"main.go:59:11: vue: template at div > button v-on:click: unknown method: Missing",
+		"main.go:61:57: vue: field Item.Done at div > ol > todo-item v-bind:done: unknown data field",
+		"main.go:61:57: vue: template at div > ol > todo-item v-bind:done: undeclared prop Done of subcomponent todo-item",
+		"main.go:63:14: vue: template at div > todo-item v-model: undeclared prop Value of subcomponent todo-item",
+		"main.go:65:39: vue: template at div > todo-status v-bind:done: undeclared prop Done of subcomponent todo-status",
+	}
+	if len(diags) != len(want) {
+		t.Fatalf("reported %v, expected %d diagnostics", diags, len(want))
+	}
+	for i, diag := range diags {
+		if got := diag.String(); !strings.HasSuffix(got, want[i]) {
+			t.Errorf("reported %q, expected %q", got, want[i])
+		}
+	}
+}
+
+func TestTemplateOffset(t *testing.T) {
+	tmpl := `<div><p :title="Message">{{ Seen }}</p><p :title="Text">{{ Missing }}</p></div>`
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{&vue.FieldError{Field: "Text", Path: "div > p", Attr: "v-bind:title"}, `:title="Text"`},
+		{&vue.FieldError{Field: "Missing", Path: "div > p"}, `Missing }}`},
+		{&vue.TemplateError{Path: "div > p", Attr: "v-bind:title"}, `:title="Message"`},
+	}
+	for _, test := range tests {
+		offset, ok := templateOffset(tmpl, test.err)
+		if !ok || !strings.HasPrefix(tmpl[offset:], test.want) {
+			t.Errorf("located %v at %d, expected %q", test.err, offset, test.want)
+		}
+	}
+	if _, ok := templateOffset(tmpl, &vue.TemplateError{Path: "div > ol"}); ok {
+		t.Error("expected unknown path not to be located")
+	}
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"errors"
+	"go/ast"
+	"go/token"
+	"io"
+	"strings"
+
+	"golang.org/x/net/html"
+
+	"github.com/tigerbot/vue"
+)
+
+// voidElements are the elements without end tags, they are never parents in the paths of templates.
+var voidElements = map[string]struct{}{
+	"area": {}, "base": {}, "br": {}, "col": {}, "embed": {}, "hr": {}, "img": {}, "input": {},
+	"link": {}, "meta": {}, "param": {}, "source": {}, "track": {}, "wbr": {},
+}
+
+// shorthands are the prefixes of the shorthands of vue attributes, e.g. v-bind:done -> :done.
+var shorthands = map[string]string{"v-bind:": ":", "v-on:": "@"}
+
+// templatePos returns the position of the error within the template literal of the component.
+// Errors are located by the path of their element and their attribute, or by their field within the text.
+// The position of the template option is returned if the error is not located,
+// e.g. templates of constants which are not literals, or literals with escapes.
+func (p *pkg) templatePos(comp *component, err error) token.Position {
+	pos := p.fset.Position(comp.tmplPos)
+	lit, ok := comp.tmplLit.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return pos
+	}
+	// Only raw literals and literals without escapes have the offsets of the template.
+	if lit.Value[0] != '`' && lit.Value[1:len(lit.Value)-1] != comp.tmpl {
+		return pos
+	}
+	offset, ok := templateOffset(comp.tmpl, err)
+	if !ok {
+		return pos
+	}
+
+	pos = p.fset.Position(lit.Pos())
+	prefix := comp.tmpl[:offset]
+	if i := strings.LastIndexByte(prefix, '\n'); i >= 0 {
+		pos.Line += strings.Count(prefix, "\n")
+		pos.Column = len(prefix) - i
+	} else {
+		// The column of the literal is the column of its opening quote.
+		pos.Column += len(prefix) + 1
+	}
+	pos.Offset += len(prefix) + 1
+	return pos
+}
+
+// templateOffset returns the offset of the error within the template.
+// The element of the path with the attribute is located, preferring the element which references the field.
+// Errors without an attribute are located by the field within the text of the element.
+func templateOffset(tmpl string, err error) (int, bool) {
+	var path, attr, field string
+	var tmplErr *vue.TemplateError
+	var fieldErr *vue.FieldError
+	switch {
+	case errors.As(err, &fieldErr):
+		path, attr, field = fieldErr.Path, fieldErr.Attr, fieldErr.Field
+	case errors.As(err, &tmplErr):
+		path, attr = tmplErr.Path, tmplErr.Attr
+	default:
+		return 0, false
+	}
+	if path == "" {
+		return 0, false
+	}
+	if i := strings.IndexAny(field, ".["); i >= 0 {
+		field = field[:i]
+	}
+
+	z := html.NewTokenizer(strings.NewReader(tmpl))
+	var stack []string
+	offset, found, inElement := 0, -1, 0
+	for {
+		typ := z.Next()
+		if typ == html.ErrorToken {
+			if z.Err() == io.EOF && found >= 0 {
+				return found, true
+			}
+			return 0, false
+		}
+		raw := string(z.Raw())
+		start := offset
+		offset += len(raw)
+
+		switch typ {
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, _ := z.TagName()
+			elem := string(name)
+			elemPath := strings.Join(append(stack, elem), " > ")
+			if _, void := voidElements[elem]; !void && typ == html.StartTagToken {
+				stack = append(stack, elem)
+			}
+			if elemPath != path {
+				continue
+			}
+			if attr == "" {
+				if found < 0 {
+					found = start
+				}
+				inElement = len(stack)
+				continue
+			}
+			i := attrIndex(raw, attr)
+			if i < 0 {
+				continue
+			}
+			if field == "" || strings.Contains(raw[i:], field) {
+				return start + i, true
+			}
+			if found < 0 {
+				found = start + i
+			}
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			for i := len(stack) - 1; i >= 0; i-- {
+				if stack[i] == string(name) {
+					stack = stack[:i]
+					break
+				}
+			}
+			if len(stack) < inElement {
+				inElement = 0
+			}
+		case html.TextToken:
+			if inElement == 0 || field == "" {
+				continue
+			}
+			if i := strings.Index(raw, field); i >= 0 {
+				return start + i, true
+			}
+		}
+	}
+}
+
+// attrIndex returns the index of the vue attribute within the raw tag, or -1.
+// The attribute may be written with its shorthand, e.g. :done for v-bind:done.
+func attrIndex(raw, attr string) int {
+	keys := []string{attr}
+	for prefix, shorthand := range shorthands {
+		if strings.HasPrefix(attr, prefix) {
+			keys = append(keys, shorthand+attr[len(prefix):])
+		}
+	}
+	for _, key := range keys {
+		for i := 0; i+len(key) <= len(raw); {
+			j := strings.Index(raw[i:], key)
+			if j < 0 {
+				break
+			}
+			i += j
+			// The key must be a whole attribute, e.g. not :done of v-bind:done.
+			before, after := byte(' '), byte('>')
+			if i > 0 {
+				before = raw[i-1]
+			}
+			if end := i + len(key); end < len(raw) {
+				after = raw[end]
+			}
+			if isSpace(before) && (after == '=' || after == '>' || after == '/' || isSpace(after)) {
+				return i
+			}
+			i += len(key)
+		}
+	}
+	return -1
+}
+
+// isSpace returns true for the whitespace between attributes.
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
@@ -0,0 +1,48 @@
+// Command vuecheck checks the templates of vue components against their data, props, computed and methods.
+//
+// The packages of the given directories are type-checked from source,
+// then every template option of a component is checked as if the component was created, without a browser.
+// For example: vuecheck ./examples/04-loops ./examples/07-composing-with-components
+//
+// Unknown fields, v-html bindings which are not strings, v-model bindings of types which the element does not support,
+// v-on methods which are not registered, and props which are not declared by subcomponents are reported.
+// The props of subcomponents which are not created by Component in the package, e.g. by a helper function
+// or imported from another package, are not known and not checked.
+// Errors are reported at their positions within template literals, otherwise at the template option.
+// The exit status is 1 if any template error is reported.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: vuecheck [dir ...]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	dirs := flag.Args()
+	if len(dirs) == 0 {
+		dirs = []string{"."}
+	}
+
+	failed := false
+	for _, dir := range dirs {
+		diags, err := checkDir(dir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "vuecheck: %v\n", err)
+			os.Exit(2)
+		}
+		for _, diag := range diags {
+			fmt.Println(diag)
+			failed = true
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"log"
+
+	"github.com/tigerbot/vue"
+)
+
+type Data struct {
+	Message string
+	Seen    bool
+	Todos   []Todo
+}
+
+type Todo struct {
+	Text string
+}
+
+type StatusProps struct {
+	State string `default:"idle"`
+}
+
+func Reverse(vctx vue.Context) {}
+
+func newItem() *vue.Comp {
+	comp, err := vue.Component(
+		vue.Props("Todo"),
+		vue.Template(`<li>{{ Todo.Text }}</li>`),
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return comp
+}
+
+func main() {
+	item, err := vue.Component(
+		vue.Props("Todo"),
+		vue.Template(`<li>{{ Todo.Text }}</li>`),
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	status, err := vue.Component(
+		vue.PropsOf(StatusProps{}),
+		vue.Template(`<span>{{ State }}</span>`),
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	_, err = vue.New(
+		vue.El("#app"),
+		vue.Template(`
+<div>
+  <p v-on:click="Reverse">{{ Mesage }}</p>
+  <input v-model="Seen">
+  <button v-on:click="Missing"></button>
+  <ol>
+    <todo-item v-for="Item in Todos" v-bind:todo="Item" v-bind:done="Item.Done"></todo-item>
+  </ol>
+  <todo-item v-model="Seen"></todo-item>
+  <form v-on:submit.prevent="Reverse($event)"></form>
+  <todo-status v-bind:state="Message" v-bind:done="Seen"></todo-status>
+  <helper-item v-bind:todo="Message"></helper-item>
+</div>
+`),
+		vue.Data(&Data{}),
+		vue.Methods(Reverse),
+		vue.Sub("todo-item", item),
+		vue.Sub("todo-status", status),
+		vue.Sub("helper-item", newItem()),
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
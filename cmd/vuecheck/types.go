@@ -0,0 +1,147 @@
+package main
+
+import (
+	"go/types"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/tigerbot/vue"
+)
+
+// basicKinds maps the kinds of basic types to reflect kinds.
+var basicKinds = map[types.BasicKind]reflect.Kind{
+	types.Bool:          reflect.Bool,
+	types.Int:           reflect.Int,
+	types.Int8:          reflect.Int8,
+	types.Int16:         reflect.Int16,
+	types.Int32:         reflect.Int32,
+	types.Int64:         reflect.Int64,
+	types.Uint:          reflect.Uint,
+	types.Uint8:         reflect.Uint8,
+	types.Uint16:        reflect.Uint16,
+	types.Uint32:        reflect.Uint32,
+	types.Uint64:        reflect.Uint64,
+	types.Uintptr:       reflect.Uintptr,
+	types.Float32:       reflect.Float32,
+	types.Float64:       reflect.Float64,
+	types.Complex64:     reflect.Complex64,
+	types.Complex128:    reflect.Complex128,
+	types.String:        reflect.String,
+	types.UnsafePointer: reflect.UnsafePointer,
+}
+
+// goType is the type of a field from the static analysis of sources.
+// Fields are resolved like the mapper resolves fields of values, e.g. promoted fields of embedded structs.
+type goType struct {
+	typ types.Type
+}
+
+func (gt goType) Kind() reflect.Kind {
+	switch typ := deref(gt.typ).Underlying().(type) {
+	case *types.Basic:
+		return basicKinds[typ.Kind()]
+	case *types.Struct:
+		return reflect.Struct
+	case *types.Slice:
+		return reflect.Slice
+	case *types.Array:
+		return reflect.Array
+	case *types.Map:
+		return reflect.Map
+	case *types.Interface:
+		return reflect.Interface
+	case *types.Signature:
+		return reflect.Func
+	case *types.Chan:
+		return reflect.Chan
+	case *types.Pointer:
+		return reflect.Ptr
+	default:
+		return reflect.Invalid
+	}
+}
+
+func (gt goType) Elem() vue.Type {
+	switch typ := deref(gt.typ).Underlying().(type) {
+	case *types.Slice:
+		return goType{typ.Elem()}
+	case *types.Array:
+		return goType{typ.Elem()}
+	case *types.Map:
+		return goType{typ.Elem()}
+	case *types.Chan:
+		return goType{typ.Elem()}
+	default:
+		return nil
+	}
+}
+
+func (gt goType) Field(path string) (vue.Type, bool) {
+	typ := gt.typ
+	for path != "" {
+		typ = deref(typ)
+		// Paths below interfaces are only known with a value.
+		if types.IsInterface(typ) {
+			return goType{typ}, true
+		}
+
+		if path[0] == '[' {
+			end := strings.Index(path, "]")
+			if end < 0 {
+				return nil, false
+			}
+			key := path[1:end]
+			path = strings.TrimPrefix(path[end+1:], ".")
+
+			var ok bool
+			if typ, ok = indexType(typ, key); !ok {
+				return nil, false
+			}
+			continue
+		}
+
+		name, rest := path, ""
+		if end := strings.IndexAny(path, ".["); end >= 0 {
+			name, rest = path[:end], path[end:]
+		}
+		path = strings.TrimPrefix(rest, ".")
+
+		switch obj, _, _ := types.LookupFieldOrMethod(typ, true, nil, name); obj := obj.(type) {
+		case *types.Var:
+			if !obj.Exported() {
+				return nil, false
+			}
+			typ = obj.Type()
+		default:
+			return nil, false
+		}
+	}
+	return goType{typ}, true
+}
+
+// indexType returns the type of the items of slices, arrays and maps for the key.
+// For example: [0] for slices and arrays, or ["key"] for maps.
+func indexType(typ types.Type, key string) (types.Type, bool) {
+	switch typ := typ.Underlying().(type) {
+	case *types.Slice:
+		_, err := strconv.Atoi(key)
+		return typ.Elem(), err == nil
+	case *types.Array:
+		_, err := strconv.Atoi(key)
+		return typ.Elem(), err == nil
+	case *types.Map:
+		_, err := strconv.Unquote(key)
+		return typ.Elem(), err == nil
+	default:
+		return nil, false
+	}
+}
+
+// deref returns the element type of pointers.
+func deref(typ types.Type) types.Type {
+	if ptr, ok := typ.Underlying().(*types.Pointer); ok {
+		return ptr.Elem()
+	}
+	return typ
+}
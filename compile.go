@@ -0,0 +1,265 @@
+package vue
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/html"
+
+	"github.com/tigerbot/vue/expr"
+)
+
+// program is the compiled template of a component.
+// The template is parsed once into a directive tree, each render executes a clone of the tree.
+type program struct {
+	root *html.Node
+
+	mutex sync.Mutex
+	texts map[string]*text
+	exprs map[string]expr.Expr
+	loops map[string]*loop
+}
+
+// text is the compiled text of a text node, the expressions are interpolated between the literals.
+// For example: "Hello {{ Name }}!" -> "Hello ", Name, "!"
+type text struct {
+	literals []string
+	exprs    []expr.Expr
+}
+
+// compile compiles the template into a program.
+// Shorthands are expanded and directives are ordered ahead of execution,
+// and the expressions of vue attributes and text nodes are precompiled.
+// The template is validated, e.g. unknown vue attributes return a template error.
+func compile(tmpl string) (*program, error) {
+	root, err := parseNode(tmpl)
+	if err != nil {
+		return nil, &TemplateError{Err: err}
+	}
+	if _, ok := firstElement(root); !ok {
+		return nil, &TemplateError{Err: errors.New("failed to find first element")}
+	}
+
+	prog := &program{
+		root:  root,
+		texts: make(map[string]*text, 0),
+		exprs: make(map[string]expr.Expr, 0),
+		loops: make(map[string]*loop, 0),
+	}
+	if err := prog.compileNode(prog.root); err != nil {
+		return nil, err
+	}
+	return prog, nil
+}
+
+// compileNode recursively prepares and validates the html node for execution.
+func (prog *program) compileNode(node *html.Node) error {
+	switch node.Type {
+	case html.ElementNode:
+		expandShorthands(node)
+		for _, attr := range node.Attr {
+			if err := prog.compileAttr(node, attr); err != nil {
+				return &TemplateError{Path: location(node), Attr: attr.Key, Err: err}
+			}
+		}
+		orderAttrs(node)
+	case html.TextNode:
+		if _, err := prog.text(node.Data); err != nil {
+			return &TemplateError{Path: location(node), Err: err}
+		}
+	}
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		if err := prog.compileNode(child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// compileAttr validates the vue attribute of the node and compiles its expression.
+// The else if and else branches must follow an element with a vue if or else if attribute.
+func (prog *program) compileAttr(node *html.Node, attr html.Attribute) error {
+	if !strings.HasPrefix(attr.Key, v) {
+		return nil
+	}
+
+	typ, _ := splitAttr(attr.Key)
+	if (typ == vElseIf || typ == vElse) && !prevBranch(node) {
+		return fmt.Errorf("%s is not adjacent to an element with v-if or v-else-if", typ)
+	}
+	if typ == vElse {
+		if attr.Val != "" {
+			return errors.New("unexpected value")
+		}
+		return nil
+	}
+	if attr.Val == "" {
+		// Event handlers may only have modifiers, e.g. v-on:submit.prevent.
+		if _, arg := splitAttr(attr.Key); typ == vOn && strings.Contains(arg, ".") {
+			return nil
+		}
+		return errors.New("missing value")
+	}
+
+	src := attr.Val
+	switch typ {
+	case vBind, vElseIf, vHtml, vIf, vModel, vOn, vShow:
+	case vFor:
+		loop, err := prog.loop(attr.Val)
+		if err != nil {
+			return err
+		}
+		src = loop.src
+	default:
+		return fmt.Errorf("unknown vue attribute: %s", typ)
+	}
+
+	x, err := prog.expr(src)
+	if err != nil {
+		return err
+	}
+	if _, ok := x.(*expr.Field); !ok && typ == vModel {
+		return fmt.Errorf("%v: %s", errNotField, src)
+	}
+	return nil
+}
+
+// expr returns the compiled expression of the source.
+// Expressions are compiled once, the compiled expressions must not be modified.
+func (prog *program) expr(src string) (expr.Expr, error) {
+	prog.mutex.Lock()
+	defer prog.mutex.Unlock()
+
+	if x, ok := prog.exprs[src]; ok {
+		return x, nil
+	}
+	x, err := expr.Parse(src)
+	if err != nil {
+		return nil, err
+	}
+	prog.exprs[src] = x
+	return x, nil
+}
+
+// handler returns the source of the handler expression rendered by a template, e.g. with substituted names of loops.
+// The expression is cached with its source, so the handler is not parsed again when called.
+func (prog *program) handler(x expr.Expr) string {
+	src := x.String()
+
+	prog.mutex.Lock()
+	defer prog.mutex.Unlock()
+
+	if _, ok := prog.exprs[src]; !ok {
+		prog.exprs[src] = x
+	}
+	return src
+}
+
+// loop returns the parsed value of the vue for attribute.
+func (prog *program) loop(value string) (*loop, error) {
+	prog.mutex.Lock()
+	defer prog.mutex.Unlock()
+
+	if l, ok := prog.loops[value]; ok {
+		return l, nil
+	}
+	l, err := parseFor(value)
+	if err != nil {
+		return nil, err
+	}
+	prog.loops[value] = l
+	return l, nil
+}
+
+// text compiles the text of a text node of the template.
+// Text without expressions is not compiled and returns nil.
+func (prog *program) text(data string) (*text, error) {
+	if !strings.Contains(data, "{{") {
+		return nil, nil
+	}
+
+	prog.mutex.Lock()
+	defer prog.mutex.Unlock()
+
+	if t, ok := prog.texts[data]; ok {
+		return t, nil
+	}
+	t, err := parseText(data)
+	if err != nil {
+		return nil, err
+	}
+	prog.texts[data] = t
+	return t, nil
+}
+
+// compiledText returns the compiled text of the text node of the template, or nil without expressions.
+// Only texts of the template are compiled, so the texts of runtime content, e.g. from v-html, are never interpolated.
+func (prog *program) compiledText(data string) *text {
+	prog.mutex.Lock()
+	defer prog.mutex.Unlock()
+	return prog.texts[data]
+}
+
+// parseText splits the text into literals and the expressions between {{ and }}.
+func parseText(data string) (*text, error) {
+	t := &text{}
+	for {
+		start := strings.Index(data, "{{")
+		if start < 0 {
+			t.literals = append(t.literals, data)
+			return t, nil
+		}
+		end := strings.Index(data[start:], "}}")
+		if end < 0 {
+			return nil, fmt.Errorf("unclosed {{ in text: %s", strings.TrimSpace(data))
+		}
+		src := data[start+2 : start+end]
+		if err := checkMustache(src); err != nil {
+			return nil, err
+		}
+		x, err := expr.Parse(src)
+		if err != nil {
+			return nil, err
+		}
+		t.literals = append(t.literals, data[:start])
+		t.exprs = append(t.exprs, x)
+		data = data[start+end+2:]
+	}
+}
+
+// checkMustache returns an error for the mustache syntax which is not supported by templates.
+// For example: {{{ RawHtml }}}, {{# Todos }}, {{> partial }}
+func checkMustache(src string) error {
+	tag := strings.TrimSpace(src)
+	if tag == "" {
+		return nil
+	}
+	switch tag[0] {
+	case '{', '&':
+		return errors.New("mustache raw html {{{ }}} is not supported, use v-html instead")
+	case '#', '^', '/':
+		return fmt.Errorf("mustache sections are not supported, use v-if or v-for instead: {{%s}}", src)
+	case '>':
+		return fmt.Errorf("mustache partials are not supported, use subcomponents instead: {{%s}}", src)
+	case '!', '=':
+		return fmt.Errorf("mustache tag is not supported: {{%s}}", src)
+	}
+	return nil
+}
+
+// cloneNode recursively clones the html node without a parent nor siblings.
+func cloneNode(node *html.Node) *html.Node {
+	clone := &html.Node{
+		Type:      node.Type,
+		DataAtom:  node.DataAtom,
+		Data:      node.Data,
+		Namespace: node.Namespace,
+		Attr:      append([]html.Attribute(nil), node.Attr...),
+	}
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		clone.AppendChild(cloneNode(child))
+	}
+	return clone
+}
@@ -1,26 +1,49 @@
 // Package vue is the progressive framework for wasm applications.
+//
+// Templates are html with vue attributes and {{ }} expressions, they are not mustache templates.
+// Mustache raw html {{{ }}}, sections, partials and comments are template errors,
+// use v-html, v-if, v-for and subcomponents instead.
 package vue
 
 import (
+	"errors"
 	"fmt"
 	"reflect"
+	"sort"
+	"strings"
 )
 
 // Comp is a vue component.
 type Comp struct {
 	el       string
+	doc      Document
 	tmpl     string
+	prog     *program
 	data     interface{}
 	methods  map[string]reflect.Value
 	computed map[string]reflect.Value
 	watchers map[string]reflect.Value
 	props    map[string]struct{}
+	emits    map[string]struct{}
 	subs     map[string]*Comp
+	hooks    map[hook]func(Context)
+	handler  func(Context, error)
 	isSub    bool
+	hydrate  bool
+	mismatch func(string)
+
+	propsType  reflect.Type
+	propFields []*propField
 }
 
+// contextType is the type of the context received by functions.
+var contextType = reflect.TypeOf((*Context)(nil)).Elem()
+
 // Component creates a new component from the given options.
-func Component(options ...Option) *Comp {
+// The functions of the options and the template are validated,
+// e.g. the template references unknown fields or methods.
+// All the errors found are returned as Diagnostics.
+func Component(options ...Option) (*Comp, error) {
 	comp := &Comp{
 		data:     struct{}{},
 		methods:  make(map[string]reflect.Value, 0),
@@ -28,12 +51,101 @@ func Component(options ...Option) *Comp {
 		watchers: make(map[string]reflect.Value, 0),
 		props:    make(map[string]struct{}, 0),
 		subs:     make(map[string]*Comp, 0),
+		hooks:    make(map[hook]func(Context), 0),
 	}
 
 	for _, option := range options {
 		option(comp)
 	}
-	return comp
+	diags := comp.validate()
+	if len(diags) > 0 {
+		return nil, diags
+	}
+
+	prog, err := compile(comp.tmpl)
+	if err != nil {
+		return nil, Diagnostics{err}
+	}
+	comp.prog = prog
+	if diags := comp.check(); len(diags) > 0 {
+		return nil, diags
+	}
+	return comp, nil
+}
+
+// validate validates the data, the functions, the props and the subcomponents of the component.
+func (comp *Comp) validate() Diagnostics {
+	var diags Diagnostics
+	data := reflect.ValueOf(comp.data)
+	if !data.IsValid() {
+		diags = append(diags, errors.New("vue: data: nil data"))
+	} else if typ := data.Type(); typ.Kind() == reflect.Func && (typ.NumIn() != 0 || typ.NumOut() != 1) {
+		diags = append(diags, fmt.Errorf("vue: data: expected func() Type, got %v", typ))
+	}
+	for _, name := range sortedKeys(comp.methods) {
+		if err := validateFunc(comp.methods[name], -1, -1); err != nil {
+			diags = append(diags, fmt.Errorf("vue: method %s: %v", name, err))
+		}
+	}
+	for _, name := range sortedKeys(comp.computed) {
+		if err := validateFunc(comp.computed[name], 1, 1); err != nil {
+			diags = append(diags, fmt.Errorf("vue: computed %s: %v", name, err))
+		}
+	}
+	for _, field := range sortedKeys(comp.watchers) {
+		fn := comp.watchers[field]
+		if err := validateFunc(fn, 3, -1); err != nil {
+			diags = append(diags, fmt.Errorf("vue: watcher %s: %v", field, err))
+		} else if fn.Type().In(1) != fn.Type().In(2) {
+			diags = append(diags, fmt.Errorf("vue: watcher %s: expected new and old values of the same type, got %v", field, fn.Type()))
+		}
+	}
+	diags = append(diags, comp.validateProps()...)
+	for element, sub := range comp.subs {
+		if sub == nil {
+			diags = append(diags, fmt.Errorf("vue: subcomponent %s: nil component", element))
+		}
+	}
+	return diags
+}
+
+// emitsEvent returns true if the component may emit the event.
+// Without the emits option, components may emit any event.
+func (comp *Comp) emitsEvent(event string) bool {
+	if comp.emits == nil {
+		return true
+	}
+	_, ok := comp.emits[strings.ToLower(event)]
+	return ok
+}
+
+// sortedKeys returns the sorted names of the functions.
+func sortedKeys(functions map[string]reflect.Value) []string {
+	keys := make([]string, 0, len(functions))
+	for key := range functions {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// validateFunc validates that the function accepts context as the first argument.
+// The number of arguments and return values are validated unless negative.
+func validateFunc(fn reflect.Value, numIn, numOut int) error {
+	if fn.Kind() != reflect.Func {
+		return fmt.Errorf("expected a function, got %v", fn.Kind())
+	}
+	typ := fn.Type()
+	if typ.NumIn() == 0 || typ.In(0) != contextType {
+		return fmt.Errorf("expected context as the first argument, got %v", typ)
+	}
+	if numIn >= 0 && typ.NumIn() != numIn {
+		return fmt.Errorf("expected %d arguments, got %v", numIn, typ)
+	}
+	if numOut >= 0 && typ.NumOut() != numOut {
+		return fmt.Errorf("expected %d return values, got %v", numOut, typ)
+	}
+	return nil
 }
 
 // newData creates new data from the function.
@@ -43,9 +155,5 @@ func (comp *Comp) newData() reflect.Value {
 	if value.Type().Kind() != reflect.Func {
 		return value
 	}
-	rets := value.Call(nil)
-	if n := len(rets); n != 1 {
-		must(fmt.Errorf("invalid return length: %d", n))
-	}
-	return rets[0]
+	return value.Call(nil)[0]
 }
@@ -9,45 +9,163 @@ import (
 )
 
 // Context is received by functions to interact with the component.
+// Functions called on the update loop, e.g. methods, watchers and computed, receive a context of the loop.
+// Methods called with Go run concurrently and receive a context which synchronizes with the loop,
+// so their data must be accessed through the context instead of mutating Data.
 type Context interface {
 	Data() interface{}
 	Get(field string) interface{}
 	Set(field string, value interface{})
+	Props() interface{}
 	Go(method string, args ...interface{})
 	Emit(event string, args ...interface{})
+	Bus() *Bus
+	NextTick(fn func())
+}
+
+// loopContext is the context of functions called on the update loop.
+// The update loop is already locked, so calls are not synchronized.
+type loopContext struct {
+	vm *ViewModel
+}
+
+func (ctx *loopContext) Data() interface{} {
+	// The reads of the data are not tracked.
+	if c := ctx.vm.tracking; c != nil {
+		c.untracked = true
+	}
+	return ctx.vm.data.Interface()
+}
+
+func (ctx *loopContext) Get(field string) interface{} {
+	return ctx.vm.get(field)
+}
+
+func (ctx *loopContext) Set(field string, value interface{}) {
+	ctx.vm.set(field, value)
+}
+
+func (ctx *loopContext) Props() interface{} {
+	return ctx.vm.typedProps()
+}
+
+func (ctx *loopContext) Go(method string, args ...interface{}) {
+	ctx.vm.goCall(method, args)
+}
+
+func (ctx *loopContext) Emit(event string, args ...interface{}) {
+	ctx.vm.emit(event, args)
+}
+
+func (ctx *loopContext) Bus() *Bus {
+	return &Bus{vm: ctx.vm, loop: true}
+}
+
+func (ctx *loopContext) NextTick(fn func()) {
+	vm := ctx.vm
+	vm.sched.nextTick(func() {
+		defer vm.catch()
+		fn()
+	})
 }
 
 // Data returns the data for the component.
 // Props and computed are excluded from data.
+// The data must not be mutated outside of the update loop, use Set instead.
 func (vm *ViewModel) Data() interface{} {
-	return vm.data.Interface()
+	defer vm.sched.enter()()
+	return vm.ctx.Data()
 }
 
 // Get returns the data field value.
 // Props and computed are included to get.
-func (vm *ViewModel) Get(field string) interface{} {
+// Unknown fields are passed to the error handler and return nil.
+func (vm *ViewModel) Get(field string) (value interface{}) {
+	defer vm.sched.enter()()
+	defer vm.catch()
+	return vm.get(field)
+}
+
+// Set assigns the data field to the given value.
+// Props and computed are excluded to set.
+// Unknown fields and values of other types are passed to the error handler.
+func (vm *ViewModel) Set(field string, value interface{}) {
+	defer vm.sched.enter()()
+	defer vm.catch()
+	vm.set(field, value)
+}
+
+// Props returns the typed props of the subcomponent, e.g. Props().(TodoProps).
+// Without the typed props option, the props are nil.
+func (vm *ViewModel) Props() interface{} {
+	defer vm.sched.enter()()
+	return vm.ctx.Props()
+}
+
+// Go asynchronously calls the given method with optional arguments.
+// Blocking functions must be called asynchronously.
+// The method runs concurrently to the update loop and receives a context which synchronizes with the loop.
+func (vm *ViewModel) Go(method string, args ...interface{}) {
+	vm.goCall(method, args)
+}
+
+// Emit dispatches the given event with optional arguments to the handlers of the parent listening to the instance,
+// e.g. Emit("remove", todo) calls Remove of <todo-item v-on:remove="Remove"></todo-item>.
+// The update event of a prop bound by a vue model attribute sets the data field of the parent,
+// e.g. Emit("update:Value", value).
+func (vm *ViewModel) Emit(event string, args ...interface{}) {
+	defer vm.sched.enter()()
+	defer vm.catch()
+	vm.emit(event, args)
+}
+
+// Bus returns the event bus of the app, the methods of the component are subscribed to its topics.
+func (vm *ViewModel) Bus() *Bus {
+	return &Bus{vm: vm}
+}
+
+// NextTick calls the function after the next render is applied to the dom.
+// Renders are batched and applied asynchronously.
+// The function is called outside of the update loop.
+func (vm *ViewModel) NextTick(fn func()) {
+	defer vm.sched.enter()()
+	vm.sched.nextTickUnlocked(fn)
+}
+
+// typedProps returns the props as a value of the props struct, or nil without the typed props option.
+func (vm *ViewModel) typedProps() interface{} {
+	if vm.comp.propsType == nil {
+		return nil
+	}
+	return vm.comp.propsValue(vm.props).Interface()
+}
+
+// get returns the data field value, panics with a field error for unknown fields.
+func (vm *ViewModel) get(field string) interface{} {
 	if rv := vm.getValue(field); rv.IsValid() {
 		return rv.Interface()
 	}
-	panic(fmt.Errorf("unknown data field: %s", field))
+	panic(&FieldError{Field: field, Err: errUnknownField})
 }
+
+// getValue returns the data field value, props and computed are included.
+// The field is tracked as a dependency if a computed property is being evaluated.
 func (vm *ViewModel) getValue(field string) reflect.Value {
+	rv := vm.lookup(field)
+	vm.track(field, rv)
+	return rv
+}
+
+// lookup returns the data field value without tracking, props and computed are included.
+func (vm *ViewModel) lookup(field string) reflect.Value {
 	if rv := mapper.GetField(vm.data, field); rv.IsValid() {
 		return rv
 	}
 
-	var topLevel, subPath string
-	if ind := strings.IndexAny(field, ".["); ind < 0 {
-		topLevel = field
-	} else if field[ind] == '[' {
-		topLevel, subPath = field[:ind], field[ind:]
-	} else {
-		topLevel, subPath = field[:ind], field[ind+1:]
-	}
-
+	topLevel, subPath := splitField(field)
 	value, ok := vm.props[topLevel]
 	if !ok {
-		value, ok = vm.cache[topLevel]
+		value, ok = vm.computedValue(topLevel)
 	}
 
 	var rv reflect.Value
@@ -61,12 +179,29 @@ func (vm *ViewModel) getValue(field string) reflect.Value {
 	return rv
 }
 
-// Set assigns the data field to the given value.
-// Props and computed are excluded to set.
-func (vm *ViewModel) Set(field string, newVal interface{}) {
+// splitField splits the field into the top level field and the sub path.
+// For example: Todos[0].Text -> Todos, [0].Text
+func splitField(field string) (string, string) {
+	ind := strings.IndexAny(field, ".[")
+	switch {
+	case ind < 0:
+		return field, ""
+	case field[ind] == '[':
+		return field[:ind], field[ind:]
+	default:
+		return field[:ind], field[ind+1:]
+	}
+}
+
+// set assigns the data field to the given value then queues a render.
+func (vm *ViewModel) set(field string, newVal interface{}) {
 	fieldVal := mapper.GetField(vm.data, field)
 	if fieldVal.Kind() == reflect.Invalid {
-		panic(fmt.Errorf("unknown data field: %s", field))
+		panic(&FieldError{Field: field, Err: errUnknownField})
+	}
+	if newVal == nil || !reflect.TypeOf(newVal).AssignableTo(fieldVal.Type()) {
+		err := fmt.Errorf("value of type %T is not assignable to %v", newVal, fieldVal.Type())
+		panic(&FieldError{Field: field, Err: err})
 	}
 
 	oldVal := fieldVal.Interface()
@@ -75,62 +210,184 @@ func (vm *ViewModel) Set(field string, newVal interface{}) {
 	}
 
 	fieldVal.Set(reflect.ValueOf(newVal))
+	vm.invalidate(field)
 	if watcher, ok := vm.comp.watchers[field]; ok {
+		// The watcher may mutate any data.
+		vm.mutated = true
 		watcher.Call([]reflect.Value{
-			reflect.ValueOf(vm),
+			reflect.ValueOf(vm.ctx),
 			reflect.ValueOf(newVal),
 			reflect.ValueOf(oldVal),
 		})
 	}
 
-	vm.render()
+	vm.sched.queueRender(vm)
 }
 
-// Go asynchronously calls the given method with optional arguments.
-// Blocking functions must be called asynchronously.
-func (vm *ViewModel) Go(method string, args ...interface{}) {
-	values := make([]reflect.Value, 0, len(args))
+// goCall calls the given method with optional arguments in a new goroutine.
+// The method receives the view model as context, then a render is queued on the update loop.
+func (vm *ViewModel) goCall(method string, args []interface{}) {
+	function, ok := vm.comp.methods[method]
+	if !ok {
+		return
+	}
+
+	values := make([]reflect.Value, 0, len(args)+1)
+	values = append(values, reflect.ValueOf(vm))
 	for _, arg := range args {
 		values = append(values, reflect.ValueOf(arg))
 	}
-	go vm.call(method, values)
-}
+	go func() {
+		function.Call(values)
 
-// Emit dispatches the given event with optional arguments.
-func (vm *ViewModel) Emit(event string, args ...interface{}) {
-	vm.bus.pub(event, "", args)
+		vm.sched.lock()
+		defer vm.sched.unlock()
+		// The method may mutate any data through the context.
+		vm.mutated = true
+		vm.sched.queueRender(vm)
+	}()
 }
 
-// call calls the given method with optional values then calls render.
+// call calls the given method with optional values then queues a render.
 func (vm *ViewModel) call(method string, values []reflect.Value) {
 	if function, ok := vm.comp.methods[method]; ok {
-		values = append([]reflect.Value{reflect.ValueOf(vm)}, values...)
+		values = append([]reflect.Value{reflect.ValueOf(vm.ctx)}, values...)
 		function.Call(values)
-		vm.render()
+		// The method may mutate any data.
+		vm.mutated = true
+		vm.sched.queueRender(vm)
 	}
 }
 
-// updateComputed evaluates every computed field for the component and stores the results in a
-// cache. If the resulting values differ from previous it will also trigger the relevant watchers.
-func (vm *ViewModel) updateComputed() {
-	oldCache := vm.cache
-	vm.cache = make(map[string]interface{}, len(vm.comp.computed))
+// emit calls the handlers of the parent listening to the event of the instance with optional arguments.
+// Update events of props bound to models of the parent set their data fields to the first argument.
+// Emitting an event which is not declared by the emits option panics.
+func (vm *ViewModel) emit(event string, args []interface{}) {
+	if prop := strings.TrimPrefix(event, updateEvent); prop != event {
+		if field, ok := vm.models[prop]; ok {
+			vm.parent.setModel(field, args)
+		}
+	} else if !vm.comp.emitsEvent(event) {
+		panic(fmt.Errorf("vue: undeclared event: %s", event))
+	}
+	for _, handler := range vm.listeners[strings.ToLower(event)] {
+		vm.parent.handleEmit(handler, args)
+	}
+}
 
-	values := []reflect.Value{reflect.ValueOf(vm)}
-	for computed, function := range vm.comp.computed {
-		vm.cache[computed] = function.Call(values)[0].Interface()
+// callFunc calls the given method with the arguments of an expression, then returns its first result if any.
+// Numbers are converted to the types of the parameters, e.g. literals of type int to int64.
+func (vm *ViewModel) callFunc(method string, args []interface{}) (interface{}, error) {
+	function, ok := vm.comp.methods[method]
+	if !ok {
+		return nil, fmt.Errorf("unknown method: %s", method)
 	}
 
-	for field := range oldCache {
-		watcher, ok := vm.comp.watchers[field]
-		if !ok || reflect.DeepEqual(oldCache[field], vm.cache[field]) {
-			continue
+	typ := function.Type()
+	numIn := typ.NumIn() - 1
+	if typ.IsVariadic() && len(args) < numIn-1 || !typ.IsVariadic() && len(args) != numIn {
+		return nil, fmt.Errorf("method %s called with %d arguments, expected %d", method, len(args), numIn)
+	}
+
+	values := make([]reflect.Value, 0, len(args)+1)
+	values = append(values, reflect.ValueOf(vm.ctx))
+	for i, arg := range args {
+		var in reflect.Type
+		if typ.IsVariadic() && i >= numIn-1 {
+			in = typ.In(numIn).Elem()
+		} else {
+			in = typ.In(i + 1)
 		}
 
-		watcher.Call([]reflect.Value{
-			reflect.ValueOf(vm),
-			reflect.ValueOf(vm.cache[field]),
-			reflect.ValueOf(oldCache[field]),
-		})
+		value, err := convertArg(arg, in)
+		if err != nil {
+			return nil, fmt.Errorf("argument %d of method %s: %v", i+1, method, err)
+		}
+		values = append(values, value)
+	}
+
+	out := function.Call(values)
+	if len(out) == 0 {
+		return nil, nil
+	}
+	return out[0].Interface(), nil
+}
+
+// convertArg converts the argument to a value of the type.
+// Nil is the zero value, numbers are converted between numeric kinds if their values are preserved, see convertNumber.
+// Values of other kinds are converted between types of the same kind, e.g. literals to named strings.
+// Events are converted to the events of their backend, e.g. dom.Event.
+func convertArg(arg interface{}, typ reflect.Type) (reflect.Value, error) {
+	if arg == nil {
+		return reflect.Zero(typ), nil
+	}
+	rv := reflect.ValueOf(arg)
+	if rv.Type().AssignableTo(typ) {
+		return rv, nil
+	}
+	if event, ok := arg.(Event); ok {
+		if native := reflect.ValueOf(event.Native()); native.IsValid() && native.Type().AssignableTo(typ) {
+			return native, nil
+		}
+	}
+	if isNumeric(rv.Kind()) && isNumeric(typ.Kind()) {
+		converted, ok := convertNumber(rv, typ)
+		if !ok {
+			return reflect.Value{}, fmt.Errorf("value %v of type %T overflows or truncates %v", arg, arg, typ)
+		}
+		return converted, nil
+	}
+	if rv.Kind() == typ.Kind() && rv.Type().ConvertibleTo(typ) {
+		return rv.Convert(typ), nil
+	}
+	return reflect.Value{}, fmt.Errorf("value of type %T is not assignable to %v", arg, typ)
+}
+
+// convertNumber converts the number to the numeric type, returns false unless the value is preserved.
+// Integers must not overflow nor change their sign, and fractions are not truncated to integers.
+// Floats are rounded to the precision of the type.
+func convertNumber(rv reflect.Value, typ reflect.Type) (reflect.Value, bool) {
+	converted := rv.Convert(typ)
+	if kind := typ.Kind(); kind == reflect.Float32 || kind == reflect.Float64 {
+		return converted, true
+	}
+	preserved := converted.Convert(rv.Type()).Interface() == rv.Interface()
+	return converted, preserved && isNegative(converted) == isNegative(rv)
+}
+
+// isNegative returns true if the number is negative.
+func isNegative(rv reflect.Value) bool {
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int() < 0
+	case reflect.Float32, reflect.Float64:
+		return rv.Float() < 0
+	default:
+		return false
+	}
+}
+
+// isNumeric returns true for integer and float kinds.
+func isNumeric(kind reflect.Kind) bool {
+	return reflect.Int <= kind && kind <= reflect.Float64
+}
+
+// updateComputed evaluates the computed properties which are dirty and stores the results in a cache.
+// Computed properties are only evaluated again when their dependencies are set or mutated.
+func (vm *ViewModel) updateComputed() {
+	if vm.mutated {
+		vm.mutated = false
+		vm.invalidateMutated()
+	}
+	for _, c := range vm.computeds {
+		if c.untracked && !c.dirty {
+			c.dirty = true
+			vm.invalidate(c.name)
+		}
+	}
+	for _, c := range vm.computeds {
+		if c.dirty {
+			vm.evaluate(c)
+		}
 	}
 }
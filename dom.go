@@ -0,0 +1,86 @@
+package vue
+
+// Document is a dom backend which view models render into.
+// The browser document is the default backend for wasm applications.
+// Without a browser, an in-memory document is the default backend.
+type Document interface {
+	// QuerySelector returns the first element matching the selector or nil.
+	QuerySelector(selector string) Node
+	// CreateElement creates a new element of the given tag.
+	CreateElement(tag string) Node
+	// CreateTextNode creates a new text node of the given content.
+	CreateTextNode(text string) Node
+	// Schedule schedules the function to be called asynchronously, e.g. in a microtask.
+	Schedule(fn func())
+}
+
+// Node is a node of a dom backend.
+type Node interface {
+	// NodeName returns the lower case tag of an element, otherwise #text or #comment.
+	NodeName() string
+	// Parent returns the parent element of the node or nil.
+	Parent() Node
+	// ChildNodes returns the children of the node.
+	ChildNodes() []Node
+	// Attributes returns the attributes of the element.
+	Attributes() map[string]string
+	// SetAttribute sets an attribute of the element.
+	SetAttribute(key, val string)
+	// RemoveAttribute removes an attribute from the element.
+	RemoveAttribute(key string)
+	// Property returns the property of the element, e.g. value.
+	Property(key string) interface{}
+	// SetProperty sets the property of the element, e.g. value.
+	SetProperty(key string, val interface{})
+	// TextContent returns the content of the text.
+	TextContent() string
+	// SetTextContent sets the content of the text.
+	SetTextContent(text string)
+	// AppendChild appends the child to the node.
+	AppendChild(child Node)
+	// InsertBefore inserts the child before the reference child.
+	InsertBefore(child, ref Node)
+	// ReplaceChild replaces a child with a new child.
+	ReplaceChild(newChild, oldChild Node)
+	// RemoveChild removes a child from the node.
+	RemoveChild(child Node)
+	// AddEventListener adds the callback as an event listener of the given type with the options.
+	// The returned function removes the event listener.
+	AddEventListener(typ string, options ListenerOptions, cb func(Event)) func()
+}
+
+// ListenerOptions are the options of an event listener.
+type ListenerOptions struct {
+	// Capture calls the listener while the event is captured down to the target, instead of bubbling up.
+	Capture bool
+	// Passive listeners never cancel the default action of the event, e.g. scrolling.
+	Passive bool
+}
+
+// Event is an event of a dom backend.
+type Event interface {
+	// Type returns the type of the event, e.g. click.
+	Type() string
+	// Target returns the node which dispatched the event.
+	Target() Node
+	// Key returns the key of a keyboard event, otherwise empty.
+	Key() string
+	// Button returns the button of a mouse event, 0 for the main button, otherwise -1.
+	Button() int
+	// CtrlKey returns true if the control key was pressed.
+	CtrlKey() bool
+	// AltKey returns true if the alt key was pressed.
+	AltKey() bool
+	// ShiftKey returns true if the shift key was pressed.
+	ShiftKey() bool
+	// MetaKey returns true if the meta key was pressed.
+	MetaKey() bool
+	// PreventDefault cancels the default action of the event.
+	PreventDefault()
+	// StopPropagation stops propagation of the event to parent nodes.
+	StopPropagation()
+	// StopImmediatePropagation stops propagation of the event to any other listener.
+	StopImmediatePropagation()
+	// Native returns the event of the backend, e.g. the dom.Event of the browser document.
+	Native() interface{}
+}
@@ -0,0 +1,9 @@
+//go:build !js || !wasm
+// +build !js !wasm
+
+package vue
+
+// defaultDocument returns an empty in-memory document without a browser.
+func defaultDocument() Document {
+	return NewMemDocument("")
+}
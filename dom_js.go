@@ -0,0 +1,224 @@
+//go:build js && wasm
+// +build js,wasm
+
+package vue
+
+import (
+	"strings"
+	"syscall/js"
+
+	dom "honnef.co/go/js/dom/v2"
+)
+
+// jsDocument is the browser document backend.
+type jsDocument struct {
+	doc dom.Document
+}
+
+// jsNode is a node of the browser document backend.
+type jsNode struct {
+	node dom.Node
+}
+
+// jsEvent is an event of the browser document backend.
+type jsEvent struct {
+	event dom.Event
+}
+
+// defaultDocument returns the browser document.
+func defaultDocument() Document {
+	return &jsDocument{doc: dom.WrapDocument(js.Global().Get("document"))}
+}
+
+// wrapNode wraps the dom node, nil nodes are preserved.
+func wrapNode(node dom.Node) Node {
+	if node == nil {
+		return nil
+	}
+	return &jsNode{node: node}
+}
+
+// unwrapNode unwraps the dom node from the node.
+func unwrapNode(node Node) dom.Node {
+	return node.(*jsNode).node
+}
+
+func (doc *jsDocument) QuerySelector(selector string) Node {
+	if elem := doc.doc.QuerySelector(selector); elem != nil {
+		return wrapNode(elem)
+	}
+	return nil
+}
+
+func (doc *jsDocument) CreateElement(tag string) Node {
+	return wrapNode(doc.doc.CreateElement(tag))
+}
+
+func (doc *jsDocument) CreateTextNode(text string) Node {
+	return wrapNode(doc.doc.CreateTextNode(text))
+}
+
+// Schedule schedules the function to be called in a microtask.
+func (doc *jsDocument) Schedule(fn func()) {
+	var cb js.Func
+	cb = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		cb.Release()
+		fn()
+		return nil
+	})
+	js.Global().Call("queueMicrotask", cb)
+}
+
+func (node *jsNode) NodeName() string {
+	return strings.ToLower(node.node.NodeName())
+}
+
+func (node *jsNode) Parent() Node {
+	if elem := node.node.ParentElement(); elem != nil {
+		return wrapNode(elem)
+	}
+	return nil
+}
+
+func (node *jsNode) ChildNodes() []Node {
+	children := node.node.ChildNodes()
+	nodes := make([]Node, 0, len(children))
+	for _, child := range children {
+		nodes = append(nodes, wrapNode(child))
+	}
+	return nodes
+}
+
+func (node *jsNode) Attributes() map[string]string {
+	if elem, ok := node.node.(dom.Element); ok {
+		return elem.Attributes()
+	}
+	return nil
+}
+
+func (node *jsNode) SetAttribute(key, val string) {
+	node.node.(dom.Element).SetAttribute(key, val)
+}
+
+func (node *jsNode) RemoveAttribute(key string) {
+	node.node.(dom.Element).RemoveAttribute(key)
+}
+
+func (node *jsNode) Property(key string) interface{} {
+	value := node.node.Underlying().Get(key)
+	switch value.Type() {
+	case js.TypeString:
+		return value.String()
+	case js.TypeBoolean:
+		return value.Bool()
+	case js.TypeNumber:
+		return value.Float()
+	default:
+		return nil
+	}
+}
+
+func (node *jsNode) SetProperty(key string, val interface{}) {
+	node.node.Underlying().Set(key, val)
+}
+
+func (node *jsNode) TextContent() string {
+	return node.node.TextContent()
+}
+
+func (node *jsNode) SetTextContent(text string) {
+	node.node.SetTextContent(text)
+}
+
+func (node *jsNode) AppendChild(child Node) {
+	node.node.AppendChild(unwrapNode(child))
+}
+
+func (node *jsNode) InsertBefore(child, ref Node) {
+	if ref == nil {
+		node.node.AppendChild(unwrapNode(child))
+		return
+	}
+	node.node.InsertBefore(unwrapNode(child), unwrapNode(ref))
+}
+
+func (node *jsNode) ReplaceChild(newChild, oldChild Node) {
+	node.node.ReplaceChild(unwrapNode(newChild), unwrapNode(oldChild))
+}
+
+func (node *jsNode) RemoveChild(child Node) {
+	node.node.RemoveChild(unwrapNode(child))
+}
+
+func (node *jsNode) AddEventListener(typ string, options ListenerOptions, cb func(Event)) func() {
+	fn := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		cb(&jsEvent{event: dom.WrapEvent(args[0])})
+		return nil
+	})
+	elem := node.node.Underlying()
+	elem.Call("addEventListener", typ, fn, map[string]interface{}{
+		"capture": options.Capture,
+		"passive": options.Passive,
+	})
+	return func() {
+		elem.Call("removeEventListener", typ, fn, options.Capture)
+		fn.Release()
+	}
+}
+
+func (event *jsEvent) Type() string {
+	return event.event.Type()
+}
+
+func (event *jsEvent) Target() Node {
+	if target := event.event.Target(); target != nil {
+		return wrapNode(target)
+	}
+	return nil
+}
+
+func (event *jsEvent) Key() string {
+	if keyEvent, ok := event.event.(*dom.KeyboardEvent); ok {
+		return keyEvent.Key()
+	}
+	return ""
+}
+
+func (event *jsEvent) Button() int {
+	if button := event.event.Underlying().Get("button"); button.Type() == js.TypeNumber {
+		return button.Int()
+	}
+	return -1
+}
+
+func (event *jsEvent) CtrlKey() bool {
+	return event.event.Underlying().Get("ctrlKey").Truthy()
+}
+
+func (event *jsEvent) AltKey() bool {
+	return event.event.Underlying().Get("altKey").Truthy()
+}
+
+func (event *jsEvent) ShiftKey() bool {
+	return event.event.Underlying().Get("shiftKey").Truthy()
+}
+
+func (event *jsEvent) MetaKey() bool {
+	return event.event.Underlying().Get("metaKey").Truthy()
+}
+
+func (event *jsEvent) PreventDefault() {
+	event.event.PreventDefault()
+}
+
+func (event *jsEvent) StopPropagation() {
+	event.event.StopPropagation()
+}
+
+func (event *jsEvent) StopImmediatePropagation() {
+	event.event.StopImmediatePropagation()
+}
+
+func (event *jsEvent) Native() interface{} {
+	return event.event
+}
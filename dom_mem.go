@@ -0,0 +1,490 @@
+package vue
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// MemDocument is an in-memory document backend built from html nodes.
+// It allows view models to be rendered and asserted on without a browser.
+// The wrappers of nodes removed from the document are released with them.
+type MemDocument struct {
+	root      *html.Node
+	nodes     map[*html.Node]*memNode
+	mutex     sync.Mutex
+	scheduled []func()
+}
+
+// memNode is a node of the in-memory document backend.
+// A removed node keeps the wrappers of its descendants until it is inserted again.
+type memNode struct {
+	doc       *MemDocument
+	node      *html.Node
+	props     map[string]interface{}
+	listeners []*memListener
+	detached  map[*html.Node]*memNode
+}
+
+// memListener is an event listener of an in-memory node.
+type memListener struct {
+	typ     string
+	options ListenerOptions
+	cb      func(Event)
+}
+
+// MemEventInit initializes an event dispatched in the in-memory document.
+type MemEventInit struct {
+	// Key is the key of a keyboard event.
+	Key string
+	// Button is the button of a mouse event, 0 for the main button.
+	Button int
+	// Ctrl, Alt, Shift and Meta are the modifier keys pressed.
+	Ctrl, Alt, Shift, Meta bool
+}
+
+// MemEvent is an event of the in-memory document backend.
+type MemEvent struct {
+	init   MemEventInit
+	typ    string
+	target *memNode
+
+	passive          bool
+	defaultPrevented bool
+	stopped          bool
+	stoppedImmediate bool
+}
+
+// NewMemDocument creates an in-memory document with the given markup as the body.
+// For example: NewMemDocument(`<div id="app"></div>`)
+func NewMemDocument(markup string) *MemDocument {
+	root, err := html.Parse(strings.NewReader(markup))
+	must(err)
+	return &MemDocument{root: root, nodes: make(map[*html.Node]*memNode, 0)}
+}
+
+// wrap wraps the html node, the same node always has the same wrapper.
+func (doc *MemDocument) wrap(node *html.Node) *memNode {
+	if n, ok := doc.nodes[node]; ok {
+		return n
+	}
+	n := &memNode{doc: doc, node: node}
+	doc.nodes[node] = n
+	return n
+}
+
+// QuerySelector returns the first element matching the selector or nil.
+// Only simple selectors of a tag, id and classes are supported, e.g. div#app.main
+func (doc *MemDocument) QuerySelector(selector string) Node {
+	if node := querySelector(doc.root, selector); node != nil {
+		return doc.wrap(node)
+	}
+	return nil
+}
+
+// CreateElement creates a new element of the given tag.
+func (doc *MemDocument) CreateElement(tag string) Node {
+	return doc.wrap(&html.Node{Type: html.ElementNode, Data: tag, DataAtom: atom.Lookup([]byte(tag))})
+}
+
+// CreateTextNode creates a new text node of the given content.
+func (doc *MemDocument) CreateTextNode(text string) Node {
+	return doc.wrap(&html.Node{Type: html.TextNode, Data: text})
+}
+
+// Schedule schedules the function to be called by the next flush.
+// Functions may be scheduled from any goroutine.
+func (doc *MemDocument) Schedule(fn func()) {
+	doc.mutex.Lock()
+	defer doc.mutex.Unlock()
+	doc.scheduled = append(doc.scheduled, fn)
+}
+
+// Flush calls the scheduled functions, including functions scheduled while flushing.
+// Flushing is deterministic, e.g. renders are applied after Flush returns.
+func (doc *MemDocument) Flush() {
+	for {
+		doc.mutex.Lock()
+		scheduled := doc.scheduled
+		doc.scheduled = nil
+		doc.mutex.Unlock()
+
+		if len(scheduled) == 0 {
+			return
+		}
+		for _, fn := range scheduled {
+			fn()
+		}
+	}
+}
+
+// HTML returns the inner html of the first element matching the selector.
+func (doc *MemDocument) HTML(selector string) string {
+	node := querySelector(doc.root, selector)
+	if node == nil {
+		return ""
+	}
+	buf := bytes.NewBuffer(nil)
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		must(html.Render(buf, child))
+	}
+	return buf.String()
+}
+
+// Dispatch dispatches an event of the given type to the target node.
+// The event is captured from the root down to the target then bubbles back up.
+// The dispatched event is returned to inspect, e.g. DefaultPrevented.
+func (doc *MemDocument) Dispatch(target Node, typ string, init MemEventInit) *MemEvent {
+	node, ok := target.(*memNode)
+	if !ok || node.doc != doc {
+		must(fmt.Errorf("target is not a node of the document: %v", target))
+	}
+	event := &MemEvent{init: init, typ: typ, target: node}
+
+	var path []*memNode
+	for n := node.node; n != nil; n = n.Parent {
+		path = append(path, doc.wrap(n))
+	}
+	for i := len(path) - 1; i >= 0 && !event.stopped; i-- {
+		path[i].dispatch(event, true)
+	}
+	for i := 0; i < len(path) && !event.stopped; i++ {
+		path[i].dispatch(event, false)
+	}
+	return event
+}
+
+// dispatch calls the listeners of the node for the event phase.
+// Listeners of the target are called once, during the capture phase.
+func (node *memNode) dispatch(event *MemEvent, capture bool) {
+	atTarget := node == event.target
+	if atTarget && !capture {
+		return
+	}
+	listeners := append([]*memListener(nil), node.listeners...)
+	for _, listener := range listeners {
+		if event.stoppedImmediate {
+			return
+		}
+		if listener.typ == event.typ && (atTarget || listener.options.Capture == capture) {
+			event.passive = listener.options.Passive
+			listener.cb(event)
+			event.passive = false
+		}
+	}
+}
+
+func (node *memNode) NodeName() string {
+	switch node.node.Type {
+	case html.TextNode:
+		return "#text"
+	case html.CommentNode:
+		return "#comment"
+	default:
+		return node.node.Data
+	}
+}
+
+func (node *memNode) Parent() Node {
+	if parent := node.node.Parent; parent != nil && parent.Type == html.ElementNode {
+		return node.doc.wrap(parent)
+	}
+	return nil
+}
+
+func (node *memNode) ChildNodes() []Node {
+	var nodes []Node
+	for child := node.node.FirstChild; child != nil; child = child.NextSibling {
+		nodes = append(nodes, node.doc.wrap(child))
+	}
+	return nodes
+}
+
+func (node *memNode) Attributes() map[string]string {
+	attrs := make(map[string]string, len(node.node.Attr))
+	for _, attr := range node.node.Attr {
+		attrs[attr.Key] = attr.Val
+	}
+	return attrs
+}
+
+func (node *memNode) SetAttribute(key, val string) {
+	for i, attr := range node.node.Attr {
+		if attr.Key == key {
+			node.node.Attr[i].Val = val
+			return
+		}
+	}
+	node.node.Attr = append(node.node.Attr, html.Attribute{Key: key, Val: val})
+}
+
+func (node *memNode) RemoveAttribute(key string) {
+	for i, attr := range node.node.Attr {
+		if attr.Key == key {
+			node.node.Attr = append(node.node.Attr[:i], node.node.Attr[i+1:]...)
+			return
+		}
+	}
+}
+
+// Property returns the property of the element.
+// Without a property set, the attribute of the same key is returned.
+func (node *memNode) Property(key string) interface{} {
+	if val, ok := node.props[key]; ok {
+		return val
+	}
+	for _, attr := range node.node.Attr {
+		if attr.Key == key {
+			return attr.Val
+		}
+	}
+	return nil
+}
+
+func (node *memNode) SetProperty(key string, val interface{}) {
+	if node.props == nil {
+		node.props = make(map[string]interface{}, 0)
+	}
+	node.props[key] = val
+}
+
+func (node *memNode) TextContent() string {
+	if node.node.Type == html.TextNode {
+		return node.node.Data
+	}
+	buf := &strings.Builder{}
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			if child.Type == html.TextNode {
+				buf.WriteString(child.Data)
+			}
+			walk(child)
+		}
+	}
+	walk(node.node)
+	return buf.String()
+}
+
+func (node *memNode) SetTextContent(text string) {
+	if node.node.Type == html.TextNode {
+		node.node.Data = text
+		return
+	}
+	for child := node.node.FirstChild; child != nil; child = node.node.FirstChild {
+		node.node.RemoveChild(child)
+		// The children are removed like by RemoveChild, so their wrappers are released.
+		node.doc.detach(node.doc.wrap(child))
+	}
+	node.node.AppendChild(&html.Node{Type: html.TextNode, Data: text})
+}
+
+func (node *memNode) AppendChild(child Node) {
+	n := child.(*memNode).node
+	if n.Parent != nil {
+		n.Parent.RemoveChild(n)
+	}
+	node.node.AppendChild(n)
+	node.doc.attach(child.(*memNode))
+}
+
+func (node *memNode) InsertBefore(child, ref Node) {
+	if ref == nil {
+		node.AppendChild(child)
+		return
+	}
+	n := child.(*memNode).node
+	if n.Parent != nil {
+		n.Parent.RemoveChild(n)
+	}
+	node.node.InsertBefore(n, ref.(*memNode).node)
+	node.doc.attach(child.(*memNode))
+}
+
+func (node *memNode) ReplaceChild(newChild, oldChild Node) {
+	node.InsertBefore(newChild, oldChild)
+	node.RemoveChild(oldChild)
+}
+
+func (node *memNode) RemoveChild(child Node) {
+	node.node.RemoveChild(child.(*memNode).node)
+	node.doc.detach(child.(*memNode))
+}
+
+// detach removes the wrappers of the removed node and its descendants from the document,
+// the removed node keeps them in case it is inserted again.
+func (doc *MemDocument) detach(removed *memNode) {
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if w, ok := doc.nodes[n]; ok {
+			if removed.detached == nil {
+				removed.detached = make(map[*html.Node]*memNode, 1)
+			}
+			removed.detached[n] = w
+			delete(doc.nodes, n)
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(removed.node)
+}
+
+// attach adds the wrappers of the inserted node and its descendants to the document.
+func (doc *MemDocument) attach(inserted *memNode) {
+	doc.nodes[inserted.node] = inserted
+	for n, w := range inserted.detached {
+		doc.nodes[n] = w
+	}
+	inserted.detached = nil
+}
+
+func (node *memNode) AddEventListener(typ string, options ListenerOptions, cb func(Event)) func() {
+	listener := &memListener{typ: typ, options: options, cb: cb}
+	node.listeners = append(node.listeners, listener)
+	return func() {
+		for i, l := range node.listeners {
+			if l == listener {
+				node.listeners = append(node.listeners[:i], node.listeners[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// String returns the outer html of the node.
+func (node *memNode) String() string {
+	buf := bytes.NewBuffer(nil)
+	must(html.Render(buf, node.node))
+	return buf.String()
+}
+
+// Type returns the type of the event.
+func (event *MemEvent) Type() string {
+	return event.typ
+}
+
+// Target returns the node which dispatched the event.
+func (event *MemEvent) Target() Node {
+	return event.target
+}
+
+// Key returns the key of a keyboard event, otherwise empty.
+func (event *MemEvent) Key() string {
+	return event.init.Key
+}
+
+// Button returns the button of the event, 0 for the main button.
+func (event *MemEvent) Button() int {
+	return event.init.Button
+}
+
+// CtrlKey returns true if the control key was pressed.
+func (event *MemEvent) CtrlKey() bool {
+	return event.init.Ctrl
+}
+
+// AltKey returns true if the alt key was pressed.
+func (event *MemEvent) AltKey() bool {
+	return event.init.Alt
+}
+
+// ShiftKey returns true if the shift key was pressed.
+func (event *MemEvent) ShiftKey() bool {
+	return event.init.Shift
+}
+
+// MetaKey returns true if the meta key was pressed.
+func (event *MemEvent) MetaKey() bool {
+	return event.init.Meta
+}
+
+// PreventDefault cancels the default action of the event.
+// Passive listeners can not cancel the default action.
+func (event *MemEvent) PreventDefault() {
+	if !event.passive {
+		event.defaultPrevented = true
+	}
+}
+
+// DefaultPrevented returns true if the default action of the event was canceled.
+func (event *MemEvent) DefaultPrevented() bool {
+	return event.defaultPrevented
+}
+
+// StopPropagation stops propagation of the event to parent nodes.
+func (event *MemEvent) StopPropagation() {
+	event.stopped = true
+}
+
+// StopImmediatePropagation stops propagation of the event to any other listener.
+func (event *MemEvent) StopImmediatePropagation() {
+	event.stopped = true
+	event.stoppedImmediate = true
+}
+
+// Native returns the event itself, the in-memory document has no other events.
+func (event *MemEvent) Native() interface{} {
+	return event
+}
+
+// querySelector finds the first element matching the simple selector by depth first search.
+func querySelector(node *html.Node, selector string) *html.Node {
+	if node.Type == html.ElementNode && matchSelector(node, selector) {
+		return node
+	}
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		if found := querySelector(child, selector); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// matchSelector matches the element against a simple selector of a tag, id and classes.
+func matchSelector(node *html.Node, selector string) bool {
+	attrs := make(map[string]string, len(node.Attr))
+	for _, attr := range node.Attr {
+		attrs[attr.Key] = attr.Val
+	}
+	classes := strings.Fields(attrs["class"])
+
+	for selector != "" {
+		end := strings.IndexAny(selector[1:], "#.") + 1
+		if end == 0 {
+			end = len(selector)
+		}
+		part := selector[:end]
+		selector = selector[end:]
+
+		switch part[0] {
+		case '#':
+			if attrs["id"] != part[1:] {
+				return false
+			}
+		case '.':
+			if !containsString(classes, part[1:]) {
+				return false
+			}
+		default:
+			if node.Data != part {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// containsString returns true if the slice contains the string.
+func containsString(slice []string, s string) bool {
+	for _, elem := range slice {
+		if elem == s {
+			return true
+		}
+	}
+	return false
+}
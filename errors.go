@@ -0,0 +1,128 @@
+package vue
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"runtime"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+var (
+	errUnknownField = errors.New("unknown data field")
+	errNilField     = errors.New("data field is nil")
+	errNotIterable  = errors.New("data field is not iterable")
+	errNotString    = errors.New("data field is not of type string")
+	errNotField     = errors.New("expression is not a data field")
+	errModelType    = errors.New("data field type is not supported by the model of the element")
+	errNumberType   = errors.New("data field type is not a number for the number modifier")
+)
+
+// TemplateError is an error in the template of a component.
+// The location is the path of the element in the template, e.g. div > ol > li,
+// and the vue attribute of the element if any, e.g. v-for.
+type TemplateError struct {
+	Path string
+	Attr string
+	Err  error
+}
+
+func (err *TemplateError) Error() string {
+	return "vue: template" + formatLocation(err.Path, err.Attr) + ": " + err.Err.Error()
+}
+
+func (err *TemplateError) Unwrap() error {
+	return err.Err
+}
+
+// FieldError is an error accessing a data field of a component.
+// The location of the template is included if the field was accessed by the template.
+type FieldError struct {
+	Field string
+	Path  string
+	Attr  string
+	Err   error
+}
+
+func (err *FieldError) Error() string {
+	return fmt.Sprintf("vue: field %s%s: %v", err.Field, formatLocation(err.Path, err.Attr), err.Err)
+}
+
+func (err *FieldError) Unwrap() error {
+	return err.Err
+}
+
+// formatLocation formats the template location of an error.
+// For example: " at div > ol > li v-for"
+func formatLocation(path, attr string) string {
+	switch {
+	case path == "":
+		return ""
+	case attr == "":
+		return " at " + path
+	default:
+		return " at " + path + " " + attr
+	}
+}
+
+// location returns the path of the html node from the root of the template.
+// For example: div > ol > li
+func location(node *html.Node) string {
+	var path []string
+	for ; node != nil && node.Data != ""; node = node.Parent {
+		if node.Type == html.ElementNode {
+			path = append([]string{node.Data}, path...)
+		}
+	}
+	return strings.Join(path, " > ")
+}
+
+// locate recovers template and field errors without a location,
+// then panics again with the location of the html node and the attribute.
+// It must be deferred while executing the node.
+func locate(node *html.Node, attr string) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	switch err := r.(type) {
+	case *TemplateError:
+		if err.Path == "" {
+			err.Path, err.Attr = location(node), attr
+		}
+	case *FieldError:
+		if err.Path == "" {
+			err.Path, err.Attr = location(node), attr
+		}
+	}
+	panic(r)
+}
+
+// catch recovers errors raised while rendering or calling functions of the component,
+// then passes them to the error handler. Runtime errors and other panics are not recovered.
+// It must be deferred on the update loop.
+func (vm *ViewModel) catch() {
+	r := recover()
+	if r == nil {
+		return
+	}
+	err, ok := r.(error)
+	if _, isRuntime := r.(runtime.Error); !ok || isRuntime {
+		panic(r)
+	}
+	vm.handleError(err)
+}
+
+// handleError passes the error to the nearest error handler of the component or its parents.
+// Without an error handler the error is logged.
+func (vm *ViewModel) handleError(err error) {
+	for handler := vm; handler != nil; handler = handler.parent {
+		if fn := handler.comp.handler; fn != nil {
+			fn(handler.ctx, err)
+			return
+		}
+	}
+	log.Print(err)
+}
@@ -0,0 +1,225 @@
+package vue
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+
+	"github.com/tigerbot/vue/expr"
+	"github.com/tigerbot/vue/mapper"
+)
+
+// scope is the lexical scope of the names of loops, e.g. Todo -> Todos[0], Index -> 0.
+// Names are looked up from the innermost loop, so names of nested loops shadow the names of outer loops.
+// The nil scope is the scope of the component without loops.
+type scope struct {
+	parent *scope
+	names  []string
+	items  []alias
+}
+
+// alias is the data path of an item of a loop,
+// or the value of the item without a path, e.g. indexes, keys and ranges.
+type alias struct {
+	path  string
+	value reflect.Value
+}
+
+// with returns a nested scope with the names bound to the items.
+func (sc *scope) with(names []string, items []alias) *scope {
+	return &scope{parent: sc, names: names, items: items}
+}
+
+// lookup returns the item bound to the name by the innermost scope.
+func (sc *scope) lookup(name string) (alias, bool) {
+	for ; sc != nil; sc = sc.parent {
+		for i, n := range sc.names {
+			if n == name {
+				return sc.items[i], true
+			}
+		}
+	}
+	return alias{}, false
+}
+
+// field returns the data path of the field path with the top level name replaced by its path.
+// Fields of items bound to values have no data path.
+func (sc *scope) field(path string) (string, bool) {
+	topLevel, subPath := splitField(path)
+	alias, ok := sc.lookup(topLevel)
+	switch {
+	case !ok:
+		return path, true
+	case alias.path == "":
+		return "", false
+	case subPath == "":
+		return alias.path, true
+	default:
+		return joinField(alias.path, subPath), true
+	}
+}
+
+// scopeValue returns the value of the field path, the names of the scope are bound before data, props and computed.
+// The data path of the field is returned if any, otherwise the field path.
+func (vm *ViewModel) scopeValue(path string, sc *scope) (reflect.Value, string) {
+	if field, ok := sc.field(path); ok {
+		return vm.getValue(field), field
+	}
+	topLevel, subPath := splitField(path)
+	alias, _ := sc.lookup(topLevel)
+	rv := alias.value
+	if subPath != "" {
+		rv = mapper.GetField(rv, subPath)
+	}
+	return rv, path
+}
+
+// eventField is the field of the event in expressions of event handlers, e.g. Select($event, Item).
+const eventField = "$event"
+
+// boundPrefix is the prefix of the names of values bound to event handlers, e.g. Remove($0).
+// Names of loops are identifiers, so they never shadow the bound values.
+const boundPrefix = "$"
+
+// env is the environment of expressions of the view model.
+// Fields are resolved through the scope of loops then looked up in data, props and computed.
+// Methods called by event handlers may mutate any data, so a render is queued.
+// The event of handlers is the event field, or the first argument of events emitted by subcomponents.
+type env struct {
+	vm      *ViewModel
+	scope   *scope
+	handler bool
+	event   interface{}
+}
+
+func (env *env) Lookup(path string) (interface{}, error) {
+	if env.handler && path == eventField {
+		return env.event, nil
+	}
+
+	rv, path := env.vm.scopeValue(path, env.scope)
+	if !rv.IsValid() {
+		return nil, &FieldError{Field: path, Err: errUnknownField}
+	}
+	return rv.Interface(), nil
+}
+
+func (env *env) Call(method string, args []interface{}) (interface{}, error) {
+	vm := env.vm
+	val, err := vm.callFunc(method, args)
+	if err != nil {
+		return nil, err
+	}
+	if env.handler {
+		vm.mutated = true
+		vm.sched.queueRender(vm)
+	}
+	return val, nil
+}
+
+// joinField joins the sub path to the field.
+// For example: Todos[0], Text -> Todos[0].Text
+func joinField(field, subPath string) string {
+	if subPath[0] == '[' {
+		return field + subPath
+	}
+	return field + "." + subPath
+}
+
+// parse returns the compiled expression of the source, panics with a template error if invalid.
+func (vm *ViewModel) parse(src string) expr.Expr {
+	x, err := vm.comp.prog.expr(src)
+	if err != nil {
+		panic(&TemplateError{Err: err})
+	}
+	return x
+}
+
+// eval evaluates the source expression within the scope of loops.
+func (vm *ViewModel) eval(src string, sc *scope) interface{} {
+	return vm.evalExpr(vm.parse(src), sc)
+}
+
+// evalExpr evaluates the expression within the scope of loops.
+// Unknown fields panic with a field error, other errors panic with a template error.
+func (vm *ViewModel) evalExpr(x expr.Expr, sc *scope) interface{} {
+	val, err := expr.Eval(x, &env{vm: vm, scope: sc})
+	if err != nil {
+		panicExpr(err)
+	}
+	return val
+}
+
+// path returns the data path of the source field within the scope of loops.
+// For example: Todo.Tags[Index] -> Todos[0].Tags[1]
+func (vm *ViewModel) path(src string, sc *scope) string {
+	path, ok := vm.fieldPath(vm.parse(src), sc)
+	if !ok {
+		panic(&FieldError{Field: src, Err: errNotField})
+	}
+	return path
+}
+
+// fieldPath returns the data path of the expression if it is a field with a data path.
+func (vm *ViewModel) fieldPath(x expr.Expr, sc *scope) (string, bool) {
+	field, ok := x.(*expr.Field)
+	if !ok {
+		return "", false
+	}
+	path, err := expr.Path(field, &env{vm: vm, scope: sc})
+	if err != nil {
+		panicExpr(err)
+	}
+	return sc.field(path)
+}
+
+// substitute replaces the names of loops of the field by their data paths, or by the names of their values
+// bound to the handlers, see bind.
+// For example: Remove(Todo, Index) -> Remove(Todos[0], $0)
+func (vm *ViewModel) substitute(field *expr.Field, sc *scope) expr.Expr {
+	alias, ok := sc.lookup(field.Name)
+	if !ok {
+		return field
+	}
+	if alias.path != "" {
+		// The compiled path is copied, it must not be modified.
+		path := vm.parse(alias.path).(*expr.Field)
+		accessors := make([]expr.Accessor, 0, len(path.Accessors)+len(field.Accessors))
+		accessors = append(append(accessors, path.Accessors...), field.Accessors...)
+		return &expr.Field{Name: path.Name, Accessors: accessors}
+	}
+	return &expr.Field{Name: vm.bind(alias.value), Accessors: field.Accessors}
+}
+
+// bind binds the value to a new name within the scope of the handlers of the current render.
+// The handlers are evaluated within the scope, until the next render replaces them.
+func (vm *ViewModel) bind(value reflect.Value) string {
+	sc := vm.binding
+	name := boundPrefix + strconv.Itoa(len(sc.names))
+	sc.names = append(sc.names, name)
+	sc.items = append(sc.items, alias{value: value})
+	return name
+}
+
+// panicExpr panics with the field error of the expression error if any, otherwise with a template error.
+func panicExpr(err error) {
+	var fieldErr *FieldError
+	if errors.As(err, &fieldErr) {
+		panic(fieldErr)
+	}
+	panic(&TemplateError{Err: err})
+}
+
+// handlerMethod returns the method of the event handler expression.
+// For example: Add -> Add, Remove(Todo) -> Remove
+func handlerMethod(x expr.Expr) string {
+	switch x := x.(type) {
+	case *expr.Field:
+		if len(x.Accessors) == 0 {
+			return x.Name
+		}
+	case *expr.Call:
+		return x.Name
+	}
+	return ""
+}
@@ -1,76 +1,368 @@
 package vue
 
 import (
+	"reflect"
+	"strconv"
 	"strings"
+	"sync/atomic"
 
-	dom "honnef.co/go/js/dom/v2"
+	"golang.org/x/net/html"
+
+	"github.com/tigerbot/vue/expr"
 )
 
+// depthProp is the property of the root elements of view models with their depth.
+// Listeners of parent components skip the elements of subcomponents by their roots.
+const depthProp = "vueDepth"
+
+// targetProp is the property of elements with handlers which identifies their virtual nodes.
+const targetProp = "vueTarget"
+
+// lastTarget is the last id of an element with handlers.
+var lastTarget uint64
+
+// nonBubbling are the types of events which do not bubble.
+// Their listeners capture the events, then the handlers are called by the target.
+var nonBubbling = map[string]struct{}{
+	"abort": {}, "blur": {}, "error": {}, "focus": {}, "invalid": {}, "load": {}, "mouseenter": {}, "mouseleave": {},
+	"pointerenter": {}, "pointerleave": {}, "resize": {}, "scroll": {}, "toggle": {}, "unload": {},
+}
+
+// eventModifiers are the modifiers of the vue on attribute which do not match keys.
+var eventModifiers = map[string]struct{}{
+	"Stop": {}, "Prevent": {}, "Capture": {}, "Once": {}, "Passive": {}, "Self": {}, "Exact": {},
+	"Ctrl": {}, "Alt": {}, "Shift": {}, "Meta": {}, "Middle": {},
+}
+
+// systemModifiers are the modifier keys which must be pressed.
+var systemModifiers = map[string]func(Event) bool{
+	"Ctrl":  Event.CtrlKey,
+	"Alt":   Event.AltKey,
+	"Shift": Event.ShiftKey,
+	"Meta":  Event.MetaKey,
+}
+
+// buttonModifiers are the buttons of mouse events.
+var buttonModifiers = map[string]int{"Left": 0, "Middle": 1, "Right": 2}
+
+// keyAliases are the keys of modifiers which are not named after a key.
+var keyAliases = map[string][]string{
+	"Esc":    {"Escape"},
+	"Space":  {" ", "Spacebar"},
+	"Up":     {"ArrowUp"},
+	"Down":   {"ArrowDown"},
+	"Left":   {"ArrowLeft"},
+	"Right":  {"ArrowRight"},
+	"Delete": {"Delete", "Backspace"},
+}
+
 // addEventListener adds the callback to the element as an event listener unless the type was previously added.
-func (vm *ViewModel) addEventListener(typ string, cb func(dom.Event)) {
-	if _, ok := vm.funcs[typ]; ok {
+// Events dispatched while the update loop is locked are handled once it is unlocked.
+// Listeners of the same type with other options are added separately, e.g. capture listeners.
+func (vm *ViewModel) addEventListener(typ string, options ListenerOptions, cb func(Event)) {
+	key := typ
+	if options.Capture {
+		key += ".capture"
+	}
+	if options.Passive {
+		key += ".passive"
+	}
+	if _, ok := vm.funcs[key]; ok {
 		return
 	}
-	fn := vm.vnode.node.AddEventListener(typ, false, cb)
-	vm.funcs[typ] = fn
+	vm.funcs[key] = vm.vnode.node.AddEventListener(typ, options, func(event Event) {
+		vm.sched.call(func() {
+			defer vm.catch()
+			cb(event)
+		})
+	})
 }
 
-// vModel is the vue model event callback.
-func (vm *ViewModel) vModel(event dom.Event) {
-	event.StopImmediatePropagation()
+// handler is an event handler of an element, rendered by the vue on and model attributes.
+// The value is the expression of the handler, or the data field of the model.
+// For example: click.stop="Remove(Todos[0])" or input.trim="Message"
+type handler struct {
+	key       string
+	typ       string
+	modifiers map[string]struct{}
+	value     string
+	model     bool
+}
 
-	target := event.Target()
-	_, field, ok := findAttr(target, event.Type())
-	if !ok {
+// eventTarget is an element with handlers on the path of an event.
+// The element is the target itself if self is true.
+type eventTarget struct {
+	elem     Node
+	handlers []*handler
+	self     bool
+}
+
+// newHandler creates the handler of the attribute rendered by a template.
+func newHandler(attr html.Attribute) *handler {
+	typ := strings.Split(attr.Key, ".")[0]
+	return &handler{
+		key:       attr.Key,
+		typ:       typ,
+		modifiers: modSet(strings.TrimPrefix(attr.Key[len(typ):], ".")),
+		value:     attr.Val,
+		model:     attr.Namespace == vModel,
+	}
+}
+
+// isHandler returns true if the attribute rendered by a template is a handler.
+func isHandler(attr html.Attribute) bool {
+	return attr.Namespace == vOn || attr.Namespace == vModel
+}
+
+// newTargetID returns a new id of an element with handlers.
+func newTargetID() string {
+	return strconv.FormatUint(atomic.AddUint64(&lastTarget, 1), 10)
+}
+
+// has returns true if the handler has the modifier.
+func (h *handler) has(modifier string) bool {
+	_, ok := h.modifiers[modifier]
+	return ok
+}
+
+// options returns the options of the listener which calls the handler.
+func (h *handler) options() ListenerOptions {
+	_, capture := nonBubbling[h.typ]
+	return ListenerOptions{Capture: capture || h.has("Capture"), Passive: h.has("Passive")}
+}
+
+// listen renders the handler attribute on the node, then adds the listener of the handler.
+func (vm *ViewModel) listen(node *html.Node, attr html.Attribute) {
+	node.Attr = append(node.Attr, attr)
+	h := newHandler(attr)
+	options := h.options()
+	vm.addEventListener(h.typ, options, vm.dispatch(options))
+}
+
+// dispatch returns the callback of the listener of the options.
+// The handlers of the elements from the target up to the root of the component are called,
+// while captured down to the target for capture listeners, otherwise while bubbling up.
+// Events which do not bubble are captured, then only capture handlers and the handlers of the target are called.
+func (vm *ViewModel) dispatch(options ListenerOptions) func(Event) {
+	return func(event Event) {
+		typ := event.Type()
+		path := vm.eventPath(event.Target())
+		for i := range path {
+			target := path[i]
+			if options.Capture {
+				target = path[len(path)-1-i]
+			}
+
+			stopped := false
+			for _, h := range target.handlers {
+				if h.typ != typ || h.options() != options || options.Capture && !h.has("Capture") && !target.self {
+					continue
+				}
+				if !matchModifiers(event, h.modifiers, target.self) {
+					continue
+				}
+
+				if h.has("Once") {
+					onceProp := "vueOnce:" + h.key
+					if once, _ := target.elem.Property(onceProp).(bool); once {
+						continue
+					}
+					target.elem.SetProperty(onceProp, true)
+				}
+				if h.has("Prevent") {
+					event.PreventDefault()
+				}
+				if h.has("Stop") {
+					event.StopPropagation()
+					stopped = true
+				}
+				vm.handle(event, target.elem, h)
+			}
+			if stopped {
+				return
+			}
+		}
+	}
+}
+
+// eventPath returns the elements with handlers from the target up to the root element of the view model.
+// Elements of subcomponents are skipped, they are handled by the listeners of the subcomponents.
+// The roots of subcomponents are kept, they may have the handlers of the parent.
+func (vm *ViewModel) eventPath(target Node) []eventTarget {
+	depth := strconv.Itoa(vm.depth)
+	var path []eventTarget
+	for elem, self := target, true; elem != nil; elem, self = elem.Parent(), false {
+		d, root := elem.Property(depthProp).(string)
+		if root && d != depth {
+			path = path[:0]
+		}
+		if id, ok := elem.Property(targetProp).(string); ok {
+			if handlers, ok := vm.targets[id]; ok {
+				path = append(path, eventTarget{elem: elem, handlers: handlers, self: self})
+			}
+		}
+		if root && d == depth {
+			break
+		}
+	}
+	return path
+}
+
+// handle calls the handler of the event on the element.
+// Handlers may be empty, e.g. an attribute with only modifiers like submit.prevent.
+// Methods of handlers of a name receive the event if they accept it, e.g. func(vctx vue.Context, event vue.Event),
+// other handlers pass the event explicitly, e.g. Select($event, Item).
+// Methods may also accept the event of the backend, e.g. func(vctx vue.Context, event dom.Event).
+func (vm *ViewModel) handle(event Event, elem Node, h *handler) {
+	if h.model {
+		vm.vModel(elem, h)
+		return
+	}
+	if h.value == "" {
 		return
 	}
+	x, err := vm.comp.prog.expr(h.value)
+	if err != nil {
+		panic(&TemplateError{Attr: h.key, Err: err})
+	}
+	if field, ok := x.(*expr.Field); ok && len(field.Accessors) == 0 {
+		vm.bus.pub(event.Type(), field.Name, vm.eventArgs(field.Name, event))
+		return
+	}
+	if _, err := expr.Eval(x, &env{vm: vm, scope: vm.bound, handler: true, event: event}); err != nil {
+		panicExpr(err)
+	}
+}
 
-	value := target.Underlying().Get("value").String()
-	vm.Set(field, value)
+// handleEmit calls the handler listening to an event emitted by a subcomponent instance.
+// Handlers of a method name receive the arguments of the event,
+// other handlers are evaluated with the first argument as the event field, e.g. Remove(Todo, $event).
+func (vm *ViewModel) handleEmit(handler string, args []interface{}) {
+	x := vm.parse(handler)
+	env := &env{vm: vm, scope: vm.bound, handler: true}
+	var err error
+	if field, ok := x.(*expr.Field); ok && len(field.Accessors) == 0 {
+		_, err = env.Call(field.Name, args)
+	} else {
+		if len(args) > 0 {
+			env.event = args[0]
+		}
+		_, err = expr.Eval(x, env)
+	}
+	if err != nil {
+		panicExpr(err)
+	}
 }
 
-// vOn is the vue on event callback.
-func (vm *ViewModel) vOn(event dom.Event) {
-	event.StopImmediatePropagation()
+// vModel sets the data field of the model from the element.
+// The value of the element is parsed into the type of the field, see parseModel.
+func (vm *ViewModel) vModel(elem Node, h *handler) {
+	field := h.value
+	fieldVal := vm.getValue(field)
+	if !fieldVal.IsValid() {
+		panic(&FieldError{Field: field, Err: errUnknownField})
+	}
+	attrs := elem.Attributes()
+	_, multiple := attrs["multiple"]
 
-	typ := event.Type()
-	attrKey, method, ok := findAttr(event.Target(), typ)
+	var value reflect.Value
+	var err error
+	switch modelKindOf(elem.NodeName(), attrs["type"], multiple) {
+	case modelCheckbox:
+		checked, _ := elem.Property("checked").(bool)
+		value, err = parseChecked(fieldVal, nodeValue(elem), checked, h.modifiers)
+	case modelSelectMultiple:
+		value, err = parseSelected(selectedValues(elem), fieldVal.Type(), h.modifiers)
+	default:
+		value, err = parseModel(nodeValue(elem), fieldVal.Type(), h.modifiers)
+	}
+	if err != nil {
+		panic(&FieldError{Field: field, Err: err})
+	}
+	vm.set(field, value.Interface())
+}
+
+// eventArgs returns the event as the argument of the method if its only argument accepts the event,
+// or the event of the backend.
+func (vm *ViewModel) eventArgs(method string, event Event) []interface{} {
+	function, ok := vm.comp.methods[method]
 	if !ok {
-		return
+		return nil
+	}
+	typ := function.Type()
+	if typ.NumIn() != 2 {
+		return nil
+	}
+	if _, err := convertArg(event, typ.In(1)); err != nil {
+		return nil
+	}
+	return []interface{}{event}
+}
+
+// matchModifiers returns true if the event matches the modifiers of the handler.
+// The self modifier only matches events of the target itself, instead of its children.
+// The system modifier keys must be pressed, only those with the exact modifier.
+// Keyboard events must match a key modifier if any, mouse events must match a button modifier if any.
+func matchModifiers(event Event, modifiers map[string]struct{}, self bool) bool {
+	if _, ok := modifiers["Self"]; ok && !self {
+		return false
 	}
 
-	modifiers := strings.TrimPrefix(attrKey, typ)
-	modSet := modSet(modifiers)
+	_, exact := modifiers["Exact"]
+	for mod, pressed := range systemModifiers {
+		_, ok := modifiers[mod]
+		if ok && !pressed(event) || exact && !ok && pressed(event) {
+			return false
+		}
+	}
 
-	if keyEvent, ok := event.(*dom.KeyboardEvent); ok {
-		key := keyEvent.Key()
-		if _, ok := modSet[key]; !ok && len(modSet) > 0 {
-			return
+	if key := event.Key(); key != "" {
+		matched, any := false, false
+		for mod := range modifiers {
+			if _, ok := eventModifiers[mod]; ok {
+				continue
+			}
+			any = true
+			if strings.EqualFold(mod, key) || containsString(keyAliases[mod], key) {
+				matched = true
+			}
 		}
+		return matched || !any
 	}
 
-	vm.bus.pub(typ, method, nil)
+	matched, any := false, false
+	for mod, button := range buttonModifiers {
+		if _, ok := modifiers[mod]; ok {
+			any = true
+			matched = matched || event.Button() == button
+		}
+	}
+	return matched || !any
 }
 
-// release removes all the event listeners.
+// release removes all the event listeners, subscriptions and received values, and releases the subcomponents.
+// The unmount hooks are only called if the view model was mounted.
 func (vm *ViewModel) release() {
-	for typ, fn := range vm.funcs {
-		vm.vnode.node.RemoveEventListener(typ, false, fn)
-		fn.Release()
+	if vm.mounted {
+		vm.callHook(hookBeforeUnmount)
 	}
-}
 
-// findAttr finds the attribute from the given prefix by searching up the dom tree.
-func findAttr(elem dom.Element, prefix string) (string, string, bool) {
-	if elem == nil {
-		return "", "", false
+	vm.dirty = false
+	vm.released = true
+	for _, remove := range vm.funcs {
+		remove()
 	}
-	for attrKey, attrVal := range elem.Attributes() {
-		if strings.HasPrefix(attrKey, prefix) {
-			return attrKey, attrVal, true
-		}
+	vm.iterated = nil
+	vm.releaseReceived()
+	vm.subs.release()
+	vm.bus.app.unsubscribe(func(sub *subscription) bool {
+		return sub.vm == vm
+	})
+
+	if vm.mounted {
+		vm.mounted = false
+		vm.queueHook(hookUnmounted)
 	}
-	return findAttr(elem.ParentElement(), prefix)
 }
 
 // modSet converts modifiers to a set, includes title conversion.
@@ -1,17 +1,24 @@
 package main
 
-import "github.com/tigerbot/vue"
+import (
+	"log"
+
+	"github.com/tigerbot/vue"
+)
 
 type Data struct {
 	Message string
 }
 
 func main() {
-	vue.New(
+	_, err := vue.New(
 		vue.El("#app"),
 		vue.Template("<p>{{ Message }}</p>"),
 		vue.Data(Data{Message: "Hello WebAssembly!"}),
 	)
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	select {}
 }
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"log"
 	"time"
 
 	"github.com/tigerbot/vue"
@@ -18,11 +19,14 @@ type Data struct {
 }
 
 func main() {
-	vue.New(
+	_, err := vue.New(
 		vue.El("#app"),
 		vue.Template(tmpl),
 		vue.Data(Data{Message: "You loaded this page on " + time.Now().Format(time.ANSIC)}),
 	)
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	select {}
 }
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"log"
 	"time"
 
 	"github.com/tigerbot/vue"
@@ -15,17 +16,19 @@ type Data struct {
 }
 
 func ToggleSeen(vctx vue.Context) {
-	data := vctx.Data().(*Data)
-	data.Seen = !data.Seen
+	vctx.Set("Seen", !vctx.Get("Seen").(bool))
 }
 
 func main() {
-	vm := vue.New(
+	vm, err := vue.New(
 		vue.El("#app"),
 		vue.Template(tmpl),
 		vue.Data(&Data{Seen: true}),
 		vue.Methods(ToggleSeen),
 	)
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	for tick := time.Tick(time.Second); ; {
 		select {
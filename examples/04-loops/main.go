@@ -1,6 +1,7 @@
 package main
 
 import (
+	"log"
 	"time"
 
 	"github.com/tigerbot/vue"
@@ -23,8 +24,8 @@ type Todo struct {
 }
 
 func Add(vctx vue.Context) {
-	data := vctx.Data().(*Data)
-	data.Todos = append(data.Todos, Todo{"Build something wasm!"})
+	todos := vctx.Get("Todos").([]Todo)
+	vctx.Set("Todos", append(todos, Todo{"Build something wasm!"}))
 }
 
 func main() {
@@ -35,12 +36,15 @@ func main() {
 		},
 	}
 
-	vm := vue.New(
+	vm, err := vue.New(
 		vue.El("#app"),
 		vue.Template(tmpl),
 		vue.Data(data),
 		vue.Methods(Add),
 	)
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	time.AfterFunc(time.Second, func() {
 		vm.Go("Add")
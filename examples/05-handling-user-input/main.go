@@ -1,6 +1,8 @@
 package main
 
 import (
+	"log"
+
 	"github.com/tigerbot/vue"
 )
 
@@ -27,12 +29,15 @@ func ReverseMessage(vctx vue.Context) {
 }
 
 func main() {
-	vue.New(
+	_, err := vue.New(
 		vue.El("#app"),
 		vue.Template(tmpl),
 		vue.Data(&Data{Message: "Hello WebAssembly!"}),
 		vue.Methods(ReverseMessage),
 	)
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	select {}
 }
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"log"
+
 	"github.com/tigerbot/vue"
 )
 
@@ -16,11 +18,14 @@ type Data struct {
 }
 
 func main() {
-	vue.New(
+	_, err := vue.New(
 		vue.El("#app"),
 		vue.Template(tmpl),
 		vue.Data(&Data{Message: "Hello WebAssembly!"}),
 	)
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	select {}
 }
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"log"
+
 	"github.com/tigerbot/vue"
 )
 
@@ -30,15 +32,23 @@ func main() {
 		},
 	}
 
-	vue.New(
+	todoItem, err := vue.Component(
+		vue.Props("Todo"),
+		vue.Template("<li>{{ Todo.Text }}</li>"),
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	_, err = vue.New(
 		vue.El("#app"),
 		vue.Template(tmpl),
 		vue.Data(data),
-		vue.Sub("todo-item", vue.Component(
-			vue.Props("Todo"),
-			vue.Template("<li>{{ Todo.Text }}</li>"),
-		)),
+		vue.Sub("todo-item", todoItem),
 	)
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	select {}
 }
@@ -1,13 +1,15 @@
 package main
 
 import (
+	"log"
+
 	"github.com/tigerbot/vue"
 )
 
 const (
 	tmpl = `
 <div>
-  <p>Using mustaches: {{{ RawHtml }}}</p>
+  <p>Using mustaches: {{ RawHtml }}</p>
   <p>Using v-html directive: <span v-html="RawHtml"></span></p>
 </div>
 `
@@ -21,11 +23,14 @@ type Data struct {
 }
 
 func main() {
-	vue.New(
+	_, err := vue.New(
 		vue.El("#app"),
 		vue.Template(tmpl),
 		vue.Data(Data{RawHtml: rawHtml}),
 	)
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	select {}
 }
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"log"
+
 	"github.com/tigerbot/vue"
 )
 
@@ -25,12 +27,15 @@ func ReversedMessage(vctx vue.Context) string {
 }
 
 func main() {
-	vue.New(
+	_, err := vue.New(
 		vue.El("#app"),
 		vue.Template(tmpl),
 		vue.Data(Data{Message: "Hello WebAssembly!"}),
 		vue.Computeds(ReversedMessage),
 	)
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	select {}
 }
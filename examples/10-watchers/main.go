@@ -2,6 +2,7 @@ package main
 
 import (
 	"encoding/json"
+	"log"
 	"net/http"
 	"strings"
 
@@ -37,10 +38,9 @@ func Answer(vctx vue.Context, newQuestion, _ string) {
 }
 
 func AsyncAnswer(vctx vue.Context) {
-	data := vctx.Data().(*Data)
 	res, err := http.Get("https://yesno.wtf/api")
 	if err != nil {
-		data.Answer = err.Error()
+		vctx.Set("Answer", err.Error())
 		return
 	}
 	defer res.Body.Close()
@@ -49,20 +49,23 @@ func AsyncAnswer(vctx vue.Context) {
 	yesno := &yesno{}
 	err = dec.Decode(yesno)
 	if err != nil {
-		data.Answer = err.Error()
+		vctx.Set("Answer", err.Error())
 		return
 	}
-	data.Answer = yesno.Answer
+	vctx.Set("Answer", yesno.Answer)
 }
 
 func main() {
-	vue.New(
+	_, err := vue.New(
 		vue.El("#app"),
 		vue.Template(tmpl),
 		vue.Data(&Data{Answer: "I cannot give you an answer until you ask a question!"}),
 		vue.Watch("Question", Answer),
 		vue.Methods(AsyncAnswer),
 	)
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	select {}
 }
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"log"
 	"math/rand"
 	"time"
 
@@ -23,18 +24,20 @@ type Class struct {
 }
 
 func Change(vctx vue.Context) {
-	data := vctx.Data().(*Data)
-	data.Class.Active = rand.Intn(2) == 1
-	data.Class.TextDanger = rand.Intn(2) == 1
+	vctx.Set("Class.Active", rand.Intn(2) == 1)
+	vctx.Set("Class.TextDanger", rand.Intn(2) == 1)
 }
 
 func main() {
-	vm := vue.New(
+	vm, err := vue.New(
 		vue.El("#app"),
 		vue.Template(tmpl),
 		vue.Data(&Data{}),
 		vue.Methods(Change),
 	)
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	for tick := time.Tick(time.Second); ; {
 		select {
@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"log"
 	"math/rand"
 	"time"
 
@@ -15,8 +16,8 @@ const tmpl = `
 `
 
 type Data struct {
-	r, g, b int
-	px      int
+	R, G, B int
+	Px      int
 }
 
 type Styles struct {
@@ -25,9 +26,8 @@ type Styles struct {
 }
 
 func Style(vctx vue.Context) *Styles {
-	data := vctx.Data().(*Data)
-	hex := fmt.Sprintf("#%02x%02x%02x", data.r, data.g, data.b)
-	size := fmt.Sprintf("%dpx", data.px)
+	hex := fmt.Sprintf("#%02x%02x%02x", vctx.Get("R"), vctx.Get("G"), vctx.Get("B"))
+	size := fmt.Sprintf("%dpx", vctx.Get("Px"))
 	return &Styles{
 		Color:    hex,
 		FontSize: size,
@@ -35,21 +35,23 @@ func Style(vctx vue.Context) *Styles {
 }
 
 func Change(vctx vue.Context) {
-	data := vctx.Data().(*Data)
-	data.r = int(rand.Float32() * 0xff)
-	data.g = int(rand.Float32() * 0xff)
-	data.b = int(rand.Float32() * 0xff)
-	data.px = 8 + (data.px-7)%64
+	vctx.Set("R", int(rand.Float32()*0xff))
+	vctx.Set("G", int(rand.Float32()*0xff))
+	vctx.Set("B", int(rand.Float32()*0xff))
+	vctx.Set("Px", 8+(vctx.Get("Px").(int)-7)%64)
 }
 
 func main() {
-	vm := vue.New(
+	vm, err := vue.New(
 		vue.El("#app"),
 		vue.Template(tmpl),
-		vue.Data(&Data{px: 8}),
+		vue.Data(&Data{Px: 8}),
 		vue.Computeds(Style),
 		vue.Methods(Change),
 	)
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	for tick := time.Tick(200 * time.Millisecond); ; {
 		select {
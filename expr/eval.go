@@ -0,0 +1,295 @@
+package expr
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+)
+
+// Env is the environment expressions are evaluated against.
+type Env interface {
+	// Lookup returns the value of the field path, e.g. Todos[0].Text.
+	Lookup(path string) (interface{}, error)
+	// Call calls the method with the arguments, then returns its first result if any.
+	Call(method string, args []interface{}) (interface{}, error)
+}
+
+// Eval evaluates the expression against the environment.
+func Eval(x Expr, env Env) (interface{}, error) {
+	switch x := x.(type) {
+	case *Literal:
+		return x.Value, nil
+	case *Field:
+		path, err := Path(x, env)
+		if err != nil {
+			return nil, err
+		}
+		return env.Lookup(path)
+	case *Unary:
+		val, err := Eval(x.X, env)
+		if err != nil {
+			return nil, err
+		}
+		return unary(x.Op, val)
+	case *Binary:
+		return evalBinary(x, env)
+	case *Call:
+		args := make([]interface{}, 0, len(x.Args))
+		for _, arg := range x.Args {
+			val, err := Eval(arg, env)
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, val)
+		}
+		return env.Call(x.Name, args)
+	default:
+		return nil, fmt.Errorf("expr: unknown expression: %T", x)
+	}
+}
+
+// Path evaluates the indexes of the field into a field path.
+// For example: Todos[Index].Text -> Todos[0].Text
+func Path(field *Field, env Env) (string, error) {
+	path := field.Name
+	for _, acc := range field.Accessors {
+		if acc.Index == nil {
+			path += "." + acc.Name
+			continue
+		}
+		index, err := Eval(acc.Index, env)
+		if err != nil {
+			return "", err
+		}
+		switch index := index.(type) {
+		case string:
+			path += "[" + strconv.Quote(index) + "]"
+		default:
+			n, ok := toInt(index)
+			if !ok {
+				return "", fmt.Errorf("expr: %s: invalid index of type %T", field, index)
+			}
+			path += "[" + strconv.FormatInt(n, 10) + "]"
+		}
+	}
+	return path, nil
+}
+
+// Truth returns the truth of the value.
+// False, nil, zero numbers, empty strings and empty slices, arrays and maps are false, otherwise true.
+func Truth(val interface{}) bool {
+	switch val := val.(type) {
+	case nil:
+		return false
+	case bool:
+		return val
+	case string:
+		return val != ""
+	}
+	if f, ok := toFloat(val); ok {
+		return f != 0
+	}
+	rv := reflect.ValueOf(val)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return rv.Len() > 0
+	case reflect.Ptr, reflect.Interface:
+		return !rv.IsNil()
+	default:
+		return true
+	}
+}
+
+// evalBinary evaluates the binary operation, && and || are short-circuited.
+func evalBinary(x *Binary, env Env) (interface{}, error) {
+	left, err := Eval(x.X, env)
+	if err != nil {
+		return nil, err
+	}
+	switch x.Op {
+	case "&&":
+		if !Truth(left) {
+			return false, nil
+		}
+	case "||":
+		if Truth(left) {
+			return true, nil
+		}
+	}
+	right, err := Eval(x.Y, env)
+	if err != nil {
+		return nil, err
+	}
+	switch x.Op {
+	case "&&", "||":
+		return Truth(right), nil
+	default:
+		val, err := binary(x.Op, left, right)
+		if err != nil {
+			return nil, fmt.Errorf("expr: %s: %v", x, err)
+		}
+		return val, nil
+	}
+}
+
+// unary applies the unary operator to the value.
+func unary(op string, val interface{}) (interface{}, error) {
+	if op == "!" {
+		return !Truth(val), nil
+	}
+	if n, ok := toInt(val); ok {
+		return int(-n), nil
+	}
+	if f, ok := toFloat(val); ok {
+		return -f, nil
+	}
+	return nil, fmt.Errorf("expr: invalid operand of %s: %T", op, val)
+}
+
+// binary applies the binary operator to the values.
+// Integers are converted to floats if either value is a float,
+// the + operator concatenates if either value is a string.
+func binary(op string, left, right interface{}) (interface{}, error) {
+	switch op {
+	case "==":
+		return equal(left, right), nil
+	case "!=":
+		return !equal(left, right), nil
+	}
+
+	ls, lok := left.(string)
+	rs, rok := right.(string)
+	if op == "+" && (lok || rok) {
+		return fmt.Sprint(left) + fmt.Sprint(right), nil
+	}
+	if lok && rok {
+		switch op {
+		case "<":
+			return ls < rs, nil
+		case "<=":
+			return ls <= rs, nil
+		case ">":
+			return ls > rs, nil
+		case ">=":
+			return ls >= rs, nil
+		}
+	}
+
+	li, liok := toInt(left)
+	ri, riok := toInt(right)
+	if liok && riok {
+		return intBinary(op, li, ri)
+	}
+	lf, lfok := toFloat(left)
+	rf, rfok := toFloat(right)
+	if lfok && rfok {
+		return floatBinary(op, lf, rf)
+	}
+	return nil, fmt.Errorf("invalid operands of types %T and %T", left, right)
+}
+
+func intBinary(op string, x, y int64) (interface{}, error) {
+	switch op {
+	case "<":
+		return x < y, nil
+	case "<=":
+		return x <= y, nil
+	case ">":
+		return x > y, nil
+	case ">=":
+		return x >= y, nil
+	case "+":
+		return int(x + y), nil
+	case "-":
+		return int(x - y), nil
+	case "*":
+		return int(x * y), nil
+	case "/", "%":
+		if y == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		if op == "/" {
+			return int(x / y), nil
+		}
+		return int(x % y), nil
+	default:
+		return nil, fmt.Errorf("invalid operator for integers: %s", op)
+	}
+}
+
+func floatBinary(op string, x, y float64) (interface{}, error) {
+	switch op {
+	case "<":
+		return x < y, nil
+	case "<=":
+		return x <= y, nil
+	case ">":
+		return x > y, nil
+	case ">=":
+		return x >= y, nil
+	case "+":
+		return x + y, nil
+	case "-":
+		return x - y, nil
+	case "*":
+		return x * y, nil
+	case "/":
+		return x / y, nil
+	default:
+		return nil, fmt.Errorf("invalid operator for floats: %s", op)
+	}
+}
+
+// equal compares the values, numbers are compared by value regardless of their types.
+// Nil equals nil values of any type, e.g. nil pointers, slices and maps.
+func equal(left, right interface{}) bool {
+	if left == nil || right == nil {
+		return isNil(left) && isNil(right)
+	}
+	if lf, ok := toFloat(left); ok {
+		rf, ok := toFloat(right)
+		return ok && lf == rf
+	}
+	return reflect.DeepEqual(left, right)
+}
+
+// isNil returns true for nil and nil values of nillable kinds.
+func isNil(val interface{}) bool {
+	if val == nil {
+		return true
+	}
+	rv := reflect.ValueOf(val)
+	switch rv.Kind() {
+	case reflect.Chan, reflect.Func, reflect.Interface, reflect.Map, reflect.Ptr, reflect.Slice, reflect.UnsafePointer:
+		return rv.IsNil()
+	default:
+		return false
+	}
+}
+
+// toInt converts values of integer kinds to int64.
+func toInt(val interface{}) (int64, bool) {
+	rv := reflect.ValueOf(val)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return int64(rv.Uint()), true
+	default:
+		return 0, false
+	}
+}
+
+// toFloat converts values of integer and float kinds to float64.
+func toFloat(val interface{}) (float64, bool) {
+	if n, ok := toInt(val); ok {
+		return float64(n), true
+	}
+	rv := reflect.ValueOf(val)
+	switch rv.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	default:
+		return 0, false
+	}
+}
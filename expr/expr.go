@@ -0,0 +1,139 @@
+// Package expr is the expression language of vue templates.
+//
+// Expressions are safe to evaluate: fields are looked up and methods are called through an environment.
+// For example: Todos[0].Done && !Seen, Count + 1 >= Max, "Hello " + Name, Remove(Item)
+//
+// Supported are literals of strings, numbers, booleans and nil, field paths with indexes,
+// calls of methods with arguments, the unary operators ! and -,
+// the binary operators || && == != < <= > >= + - * / % and parentheses.
+// Names may start with $ for special fields of the environment, e.g. $event.
+package expr
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Expr is a parsed expression.
+type Expr interface {
+	String() string
+}
+
+// Literal is a string, int, float64, bool or nil value.
+type Literal struct {
+	Value interface{}
+}
+
+// Field is a field path, e.g. Todos[Index].Text.
+// The name is the top level field, followed by accessors of sub fields and indexes.
+type Field struct {
+	Name      string
+	Accessors []Accessor
+}
+
+// Accessor accesses either a sub field by name or an index of slices, arrays and maps.
+type Accessor struct {
+	Name  string
+	Index Expr
+}
+
+// Unary is a unary operation, e.g. !Seen or -Count.
+type Unary struct {
+	Op string
+	X  Expr
+}
+
+// Binary is a binary operation, e.g. Count + 1.
+type Binary struct {
+	Op   string
+	X, Y Expr
+}
+
+// Call is a call of a method with arguments, e.g. Remove(Item).
+type Call struct {
+	Name string
+	Args []Expr
+}
+
+func (lit *Literal) String() string {
+	switch val := lit.Value.(type) {
+	case nil:
+		return "nil"
+	case string:
+		return strconv.Quote(val)
+	case int:
+		return strconv.Itoa(val)
+	case float64:
+		return strconv.FormatFloat(val, 'g', -1, 64)
+	case bool:
+		return strconv.FormatBool(val)
+	default:
+		return ""
+	}
+}
+
+func (field *Field) String() string {
+	sb := &strings.Builder{}
+	sb.WriteString(field.Name)
+	for _, acc := range field.Accessors {
+		if acc.Index != nil {
+			sb.WriteString("[" + acc.Index.String() + "]")
+		} else {
+			sb.WriteString("." + acc.Name)
+		}
+	}
+	return sb.String()
+}
+
+func (unary *Unary) String() string {
+	return unary.Op + group(unary.X)
+}
+
+func (binary *Binary) String() string {
+	return group(binary.X) + " " + binary.Op + " " + group(binary.Y)
+}
+
+func (call *Call) String() string {
+	args := make([]string, 0, len(call.Args))
+	for _, arg := range call.Args {
+		args = append(args, arg.String())
+	}
+	return call.Name + "(" + strings.Join(args, ", ") + ")"
+}
+
+// group formats the operand of an operation, binary operations are grouped with parentheses.
+func group(x Expr) string {
+	if _, ok := x.(*Binary); ok {
+		return "(" + x.String() + ")"
+	}
+	return x.String()
+}
+
+// Substitute returns a copy of the expression with the fields replaced by the results of the function,
+// e.g. to replace the item of a loop with its path: Item.Text -> Todos[0].Text
+// The indexes of a field are substituted before the field.
+func Substitute(x Expr, subst func(field *Field) Expr) Expr {
+	switch x := x.(type) {
+	case *Field:
+		field := &Field{Name: x.Name, Accessors: make([]Accessor, len(x.Accessors))}
+		for i, acc := range x.Accessors {
+			field.Accessors[i] = acc
+			if acc.Index != nil {
+				field.Accessors[i].Index = Substitute(acc.Index, subst)
+			}
+		}
+		return subst(field)
+	case *Unary:
+		return &Unary{Op: x.Op, X: Substitute(x.X, subst)}
+	case *Binary:
+		return &Binary{Op: x.Op, X: Substitute(x.X, subst), Y: Substitute(x.Y, subst)}
+	case *Call:
+		call := &Call{Name: x.Name, Args: make([]Expr, len(x.Args))}
+		for i, arg := range x.Args {
+			call.Args[i] = Substitute(arg, subst)
+		}
+		return call
+	default:
+		return x
+	}
+}
@@ -0,0 +1,141 @@
+package expr
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+type testEnv map[string]interface{}
+
+func (env testEnv) Lookup(path string) (interface{}, error) {
+	val, ok := env[path]
+	if !ok {
+		return nil, fmt.Errorf("unknown field: %s", path)
+	}
+	return val, nil
+}
+
+func (env testEnv) Call(method string, args []interface{}) (interface{}, error) {
+	if method != "Join" {
+		return nil, fmt.Errorf("unknown method: %s", method)
+	}
+	return fmt.Sprint(args...), nil
+}
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		src  string
+		want string
+	}{
+		{src: "Message", want: "Message"},
+		{src: "Todos[Index + 1].Text", want: "Todos[Index + 1].Text"},
+		{src: "!Seen && Count > 0 || Done", want: "(!Seen && (Count > 0)) || Done"},
+		{src: "1 + 2 * 3", want: "1 + (2 * 3)"},
+		{src: "(1 + 2) * 3", want: "(1 + 2) * 3"},
+		{src: `Join('a', "b\"c", -1.5, nil)`, want: `Join("a", "b\"c", -1.5, nil)`},
+		{src: "Handle($event, Todos[0])", want: "Handle($event, Todos[0])"},
+		{src: `Größe + "ü"`, want: `Größe + "ü"`},
+		{src: "Δx * 2", want: "Δx * 2"},
+		{src: `Join('it\'s', "tab\t\\", 'say "hi"', "\u00e9\n")`, want: `Join("it's", "tab\t\\", "say \"hi\"", "é\n")`},
+	}
+	for _, c := range cases {
+		x, err := Parse(c.src)
+		if err != nil {
+			t.Errorf("failed to parse %s: %v", c.src, err)
+			continue
+		}
+		if got := x.String(); got != c.want {
+			t.Errorf("parsed %s into %s, expected %s", c.src, got, c.want)
+		}
+		// Expressions are parsed again from their strings, e.g. event handlers.
+		if y, err := Parse(x.String()); err != nil || y.String() != c.want {
+			t.Errorf("parsed %s again into %v, %v, expected %s", x, y, err, c.want)
+		}
+	}
+
+	for _, src := range []string{"", "Count +", "Todos[0", "Join(1,", "'open", "a # b", "1 2", "a$b", `"\q"`, "a ° b"} {
+		if _, err := Parse(src); err == nil {
+			t.Errorf("expected error parsing %q", src)
+		}
+	}
+}
+
+func TestEval(t *testing.T) {
+	env := testEnv{
+		"Message":       "hi",
+		"Seen":          true,
+		"Count":         uint8(3),
+		"Index":         0,
+		"Todos[1].Text": "b",
+		"Todos":         []string{"a", "b"},
+		"Price":         1.5,
+		"Ptr":           (*int)(nil),
+		"Tags":          map[string]string(nil),
+	}
+	cases := []struct {
+		src  string
+		want interface{}
+	}{
+		{src: "Message", want: "hi"},
+		{src: "!Seen", want: false},
+		{src: "Count * 2 + 1", want: 7},
+		{src: "Count / 2", want: 1},
+		{src: "Price * 2", want: 3.0},
+		{src: "Count == 3.0", want: true},
+		{src: `Message + " " + Count`, want: "hi 3"},
+		{src: "Todos[Index + 1].Text", want: "b"},
+		{src: "Seen && Todos", want: true},
+		{src: "!Seen && Missing", want: false},
+		{src: "Seen || Missing", want: true},
+		{src: `"a" < "b"`, want: true},
+		{src: "Join(Message, Count)", want: "hi3"},
+		{src: "Ptr == nil", want: true},
+		{src: "nil != Tags", want: false},
+		{src: "Todos == nil", want: false},
+		{src: "Index == nil", want: false},
+		{src: "nil == nil", want: true},
+	}
+	for _, c := range cases {
+		x, err := Parse(c.src)
+		if err != nil {
+			t.Fatalf("failed to parse %s: %v", c.src, err)
+		}
+		got, err := Eval(x, env)
+		if err != nil {
+			t.Errorf("failed to evaluate %s: %v", c.src, err)
+		} else if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("evaluated %s to %#v, expected %#v", c.src, got, c.want)
+		}
+	}
+
+	for _, src := range []string{"Missing", "Count / 0", "Seen + 1", "Remove(Count)", "Todos[Seen]"} {
+		x, err := Parse(src)
+		if err != nil {
+			t.Fatalf("failed to parse %s: %v", src, err)
+		}
+		if _, err := Eval(x, env); err == nil {
+			t.Errorf("expected error evaluating %s", src)
+		}
+	}
+}
+
+func TestSubstitute(t *testing.T) {
+	x, err := Parse("Remove(Item, Item.Tags[Index], Other)")
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+	substituted := Substitute(x, func(field *Field) Expr {
+		switch field.Name {
+		case "Item":
+			return &Field{Name: "Todos", Accessors: append([]Accessor{{Index: &Literal{Value: 2}}}, field.Accessors...)}
+		case "Index":
+			return &Literal{Value: 1}
+		default:
+			return field
+		}
+	})
+	if got, want := substituted.String(), "Remove(Todos[2], Todos[2].Tags[1], Other)"; got != want {
+		t.Errorf("substituted into %s, expected %s", got, want)
+	}
+}
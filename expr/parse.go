@@ -0,0 +1,303 @@
+package expr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// precedences of the binary operators, higher binds tighter.
+var precedences = map[string]int{
+	"||": 1,
+	"&&": 2,
+	"==": 3, "!=": 3, "<": 3, "<=": 3, ">": 3, ">=": 3,
+	"+": 4, "-": 4,
+	"*": 5, "/": 5, "%": 5,
+}
+
+// operators are the operator and punctuation tokens, longer tokens first.
+var operators = []string{
+	"||", "&&", "==", "!=", "<=", ">=",
+	"<", ">", "+", "-", "*", "/", "%", "!", "(", ")", "[", "]", ".", ",",
+}
+
+// tokenKind is the kind of a token.
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenNumber
+	tokenString
+	tokenOp
+)
+
+// token is a lexed token of an expression.
+type token struct {
+	kind tokenKind
+	val  string
+	pos  int
+}
+
+// parser is a recursive descent parser of expressions.
+type parser struct {
+	src    string
+	tokens []token
+	i      int
+}
+
+// Parse parses the source into an expression.
+func Parse(src string) (Expr, error) {
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{src: src, tokens: tokens}
+	x, err := p.parseBinary(1)
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, p.errorf(tok, "unexpected %q", tok.val)
+	}
+	return x, nil
+}
+
+// lex splits the source into tokens.
+// Identifiers are lexed by runes, so they may contain any letters, e.g. Größe.
+func lex(src string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(src); {
+		c, size := utf8.DecodeRuneInString(src[i:])
+		switch {
+		case unicode.IsSpace(c):
+			i += size
+		case c == '_' || c == '$' || unicode.IsLetter(c):
+			start := i
+			for i += size; i < len(src); i += size {
+				c, size = utf8.DecodeRuneInString(src[i:])
+				if c != '_' && !unicode.IsLetter(c) && !unicode.IsDigit(c) {
+					break
+				}
+			}
+			tokens = append(tokens, token{kind: tokenIdent, val: src[start:i], pos: start})
+		case '0' <= c && c <= '9':
+			start := i
+			for i < len(src) && ('0' <= src[i] && src[i] <= '9' || src[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenNumber, val: src[start:i], pos: start})
+		case c == '"' || c == '\'':
+			val, n, err := unquote(src[i:])
+			if err != nil {
+				return nil, fmt.Errorf("expr: %s: %v at %d", src, err, i)
+			}
+			tokens = append(tokens, token{kind: tokenString, val: val, pos: i})
+			i += n
+		default:
+			op := ""
+			for _, candidate := range operators {
+				if strings.HasPrefix(src[i:], candidate) {
+					op = candidate
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("expr: %s: unexpected %q at %d", src, c, i)
+			}
+			tokens = append(tokens, token{kind: tokenOp, val: op, pos: i})
+			i += len(op)
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, pos: len(src)}), nil
+}
+
+// unquote unquotes the string literal at the start of the source, quoted with either ' or ".
+// The escapes are the escapes of Go strings, so literals are unquoted symmetrically to strconv.Quote.
+// Returns the value and the length of the literal.
+func unquote(src string) (string, int, error) {
+	quote := src[0]
+	// Literals quoted with ' are unquoted as Go strings quoted with ", e.g. 'say "hi"' -> "say \"hi\"".
+	sb := &strings.Builder{}
+	sb.WriteByte('"')
+	for i := 1; i < len(src); i++ {
+		switch c := src[i]; {
+		case c == quote:
+			sb.WriteByte('"')
+			val, err := strconv.Unquote(sb.String())
+			if err != nil {
+				return "", 0, fmt.Errorf("invalid string %s", src[:i+1])
+			}
+			return val, i + 1, nil
+		case c == '\\' && i+1 < len(src):
+			i++
+			if src[i] != '\'' {
+				sb.WriteByte(c)
+			}
+			sb.WriteByte(src[i])
+		case c == '"':
+			sb.WriteString(`\"`)
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated string")
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.i]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.i]
+	if tok.kind != tokenEOF {
+		p.i++
+	}
+	return tok
+}
+
+// accept consumes the operator token if next.
+func (p *parser) accept(op string) bool {
+	if tok := p.peek(); tok.kind == tokenOp && tok.val == op {
+		p.i++
+		return true
+	}
+	return false
+}
+
+// expect consumes the operator token, otherwise returns an error.
+func (p *parser) expect(op string) error {
+	if !p.accept(op) {
+		tok := p.peek()
+		return p.errorf(tok, "expected %q", op)
+	}
+	return nil
+}
+
+func (p *parser) errorf(tok token, format string, args ...interface{}) error {
+	if tok.kind == tokenEOF {
+		return fmt.Errorf("expr: %s: %s at end", p.src, fmt.Sprintf(format, args...))
+	}
+	return fmt.Errorf("expr: %s: %s at %d", p.src, fmt.Sprintf(format, args...), tok.pos)
+}
+
+// parseBinary parses binary operations of at least the precedence.
+func (p *parser) parseBinary(prec int) (Expr, error) {
+	x, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		tok := p.peek()
+		opPrec, ok := precedences[tok.val]
+		if tok.kind != tokenOp || !ok || opPrec < prec {
+			return x, nil
+		}
+		p.next()
+		y, err := p.parseBinary(opPrec + 1)
+		if err != nil {
+			return nil, err
+		}
+		x = &Binary{Op: tok.val, X: x, Y: y}
+	}
+}
+
+// parseUnary parses unary operations.
+func (p *parser) parseUnary() (Expr, error) {
+	if tok := p.peek(); tok.kind == tokenOp && (tok.val == "!" || tok.val == "-") {
+		p.next()
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &Unary{Op: tok.val, X: x}, nil
+	}
+	return p.parsePrimary()
+}
+
+// parsePrimary parses literals, fields, calls and parentheses.
+func (p *parser) parsePrimary() (Expr, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokenNumber:
+		if n, err := strconv.Atoi(tok.val); err == nil {
+			return &Literal{Value: n}, nil
+		}
+		f, err := strconv.ParseFloat(tok.val, 64)
+		if err != nil {
+			return nil, p.errorf(tok, "invalid number %q", tok.val)
+		}
+		return &Literal{Value: f}, nil
+	case tokenString:
+		return &Literal{Value: tok.val}, nil
+	case tokenIdent:
+		switch tok.val {
+		case "true", "false":
+			return &Literal{Value: tok.val == "true"}, nil
+		case "nil":
+			return &Literal{}, nil
+		}
+		if p.accept("(") {
+			return p.parseCall(tok.val)
+		}
+		return p.parseField(tok.val)
+	case tokenOp:
+		if tok.val == "(" {
+			x, err := p.parseBinary(1)
+			if err != nil {
+				return nil, err
+			}
+			return x, p.expect(")")
+		}
+	}
+	return nil, p.errorf(tok, "unexpected %q", tok.val)
+}
+
+// parseCall parses the arguments of a call after the opening parenthesis.
+func (p *parser) parseCall(name string) (Expr, error) {
+	call := &Call{Name: name}
+	if p.accept(")") {
+		return call, nil
+	}
+	for {
+		arg, err := p.parseBinary(1)
+		if err != nil {
+			return nil, err
+		}
+		call.Args = append(call.Args, arg)
+		if p.accept(")") {
+			return call, nil
+		}
+		if err := p.expect(","); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// parseField parses the accessors of a field after its name.
+func (p *parser) parseField(name string) (Expr, error) {
+	field := &Field{Name: name}
+	for {
+		switch {
+		case p.accept("."):
+			tok := p.next()
+			if tok.kind != tokenIdent {
+				return nil, p.errorf(tok, "expected field name")
+			}
+			field.Accessors = append(field.Accessors, Accessor{Name: tok.val})
+		case p.accept("["):
+			index, err := p.parseBinary(1)
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+			field.Accessors = append(field.Accessors, Accessor{Index: index})
+		default:
+			return field, nil
+		}
+	}
+}
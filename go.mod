@@ -3,7 +3,6 @@ module github.com/tigerbot/vue
 go 1.15
 
 require (
-	github.com/cbroglie/mustache v1.0.1
 	golang.org/x/net v0.0.0-20190311031020-56fb01167e7d
 	honnef.co/go/js/dom/v2 v2.0.0-20200509013220-d4405f7ab4d8
 )
@@ -0,0 +1,47 @@
+package vue
+
+// hook is a lifecycle hook of a component.
+type hook int
+
+const (
+	hookCreated hook = iota
+	hookMounted
+	hookUpdated
+	hookBeforeUnmount
+	hookUnmounted
+)
+
+// callHook calls the lifecycle hook of the component on the update loop.
+func (vm *ViewModel) callHook(h hook) {
+	defer vm.catch()
+	if fn, ok := vm.comp.hooks[h]; ok {
+		fn(vm.ctx)
+	}
+}
+
+// queueHook queues the lifecycle hook of the component to be called after the current render,
+// e.g. once the dom is patched.
+// Hooks of released view models are skipped, except for the unmounted hook.
+func (vm *ViewModel) queueHook(h hook) {
+	if _, ok := vm.comp.hooks[h]; !ok {
+		return
+	}
+	vm.sched.queueHook(func() {
+		if vm.released && h != hookUnmounted {
+			return
+		}
+		vm.callHook(h)
+	})
+}
+
+// queueMount queues the view model to be mounted after the current render.
+// Children are mounted before their parent since they are rendered first.
+func (vm *ViewModel) queueMount() {
+	vm.sched.queueHook(func() {
+		if vm.released {
+			return
+		}
+		vm.mounted = true
+		vm.callHook(hookMounted)
+	})
+}
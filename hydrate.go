@@ -0,0 +1,175 @@
+package vue
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// hydrate adopts the existing dom children of the node as virtual nodes by matching them to the source.
+// Mismatches between the dom and the source are reported then patched.
+// Comments and whitespace of the dom are not significant to match.
+func (dst *vnode) hydrate(src *html.Node, subs subs, mismatch func(string)) {
+	children := dst.node.ChildNodes()
+	i := 0
+	// next skips to the next dom child, optionally skipping whitespace.
+	next := func(skipSpace bool) Node {
+		for ; i < len(children); i++ {
+			child := children[i]
+			switch child.NodeName() {
+			case "#comment":
+				continue
+			case "#text":
+				if skipSpace && strings.TrimSpace(child.TextContent()) == "" {
+					continue
+				}
+			}
+			return child
+		}
+		return nil
+	}
+
+	for srcChild := src.FirstChild; srcChild != nil; srcChild = srcChild.NextSibling {
+		if srcChild.Type == html.TextNode && strings.TrimSpace(srcChild.Data) == "" {
+			domChild := next(false)
+			if domChild != nil && domChild.NodeName() == "#text" && strings.TrimSpace(domChild.TextContent()) == "" {
+				i++
+				child := &vnode{typ: html.TextNode, data: domChild.TextContent(), doc: dst.doc, node: domChild}
+				if child.data != srcChild.Data {
+					child.setText(srcChild.Data)
+				}
+				dst.adopt(child)
+				continue
+			}
+			child := createNode(dst.doc, srcChild, subs)
+			dst.node.InsertBefore(child.node, domChild)
+			dst.adopt(child)
+			continue
+		}
+
+		domChild := next(true)
+		if domChild == nil {
+			mismatch(fmt.Sprintf("missing %s in <%s>", describeSource(srcChild), dst.data))
+			dst.append(createNode(dst.doc, srcChild, subs))
+			continue
+		}
+		i++
+		dst.adopt(dst.hydrateChild(domChild, srcChild, subs, mismatch))
+	}
+
+	for domChild := next(true); domChild != nil; domChild = next(true) {
+		i++
+		mismatch(fmt.Sprintf("unexpected %s in <%s>", describeNode(domChild), dst.data))
+		dst.node.RemoveChild(domChild)
+	}
+}
+
+// hydrateChild adopts the dom child as a virtual node matching the source child.
+// The dom child is replaced if it does not match the source child.
+func (dst *vnode) hydrateChild(domChild Node, srcChild *html.Node, subs subs, mismatch func(string)) *vnode {
+	switch srcChild.Type {
+	case html.ElementNode:
+		if vm, ok := subs.instance(srcChild.Data); ok {
+			return dst.hydrateSub(domChild, srcChild, vm, mismatch)
+		}
+		if name := domChild.NodeName(); name != srcChild.Data {
+			mismatch(fmt.Sprintf("expected %s but found %s", describeSource(srcChild), describeNode(domChild)))
+			break
+		}
+
+		child := &vnode{typ: html.ElementNode, data: srcChild.Data, attrs: domChild.Attributes(), doc: dst.doc, node: domChild}
+		child.hydrateAttributes(srcChild.Attr, mismatch)
+		child.hydrate(srcChild, subs, mismatch)
+		return child
+	case html.TextNode:
+		if name := domChild.NodeName(); name != "#text" {
+			mismatch(fmt.Sprintf("expected %s but found %s", describeSource(srcChild), describeNode(domChild)))
+			break
+		}
+
+		child := &vnode{typ: html.TextNode, data: domChild.TextContent(), doc: dst.doc, node: domChild}
+		if child.data != srcChild.Data {
+			mismatch(fmt.Sprintf("expected text %q but found %q", srcChild.Data, child.data))
+			child.setText(srcChild.Data)
+		}
+		return child
+	}
+
+	child := createNode(dst.doc, srcChild, subs)
+	dst.node.ReplaceChild(child.node, domChild)
+	return child
+}
+
+// hydrateSub adopts the dom child as the root element of the instance of a subcomponent.
+// The dom child is replaced if it does not match the root element of the template of the subcomponent.
+func (dst *vnode) hydrateSub(domChild Node, srcChild *html.Node, vm *ViewModel, mismatch func(string)) *vnode {
+	subNode := vm.vnode
+	subNode.parentAttrs = srcChild.Attr
+	if vm.hydrating && domChild.NodeName() == subNode.data {
+		vm.hydrateRoot(domChild)
+		return subNode
+	}
+	if vm.hydrating {
+		mismatch(fmt.Sprintf("expected <%s> of %s but found %s", subNode.data, describeSource(srcChild), describeNode(domChild)))
+		vm.hydrating = false
+		vm.render()
+	}
+	subNode.renderRootAttributes()
+	dst.node.ReplaceChild(subNode.node, domChild)
+	return subNode
+}
+
+// hydrateRoot adopts the dom element as the root element of the subcomponent,
+// then hydrates its children by the deferred first render.
+func (vm *ViewModel) hydrateRoot(node Node) {
+	vm.vnode.node = node
+	vm.vnode.attrs = node.Attributes()
+	node.SetProperty(depthProp, strconv.Itoa(vm.depth))
+	vm.render()
+}
+
+// mismatch reports a hydration mismatch to the mismatch function of the app.
+func (vm *ViewModel) mismatch(msg string) {
+	for vm.parent != nil {
+		vm = vm.parent
+	}
+	vm.comp.mismatch(msg)
+}
+
+// hydrateAttributes reports the differences of the adopted attributes then renders the attributes.
+func (vnode *vnode) hydrateAttributes(attrs []html.Attribute, mismatch func(string)) {
+	srcAttrs := make(map[string]string, len(attrs))
+	for _, attr := range attrs {
+		if attr.Key == keyAttr || isHandler(attr) {
+			continue
+		}
+		srcAttrs[attr.Key] = attr.Val
+		if val, ok := vnode.attrs[attr.Key]; !ok || val != attr.Val {
+			mismatch(fmt.Sprintf("expected attribute %s=%q on <%s> but found %q", attr.Key, attr.Val, vnode.data, val))
+		}
+	}
+	for key, val := range vnode.attrs {
+		if _, ok := srcAttrs[key]; !ok {
+			mismatch(fmt.Sprintf("unexpected attribute %s=%q on <%s>", key, val, vnode.data))
+		}
+	}
+	vnode.renderAttributes(attrs)
+}
+
+// describeSource describes the html node for mismatches.
+func describeSource(node *html.Node) string {
+	if node.Type == html.TextNode {
+		return fmt.Sprintf("text %q", node.Data)
+	}
+	return fmt.Sprintf("<%s>", node.Data)
+}
+
+// describeNode describes the dom node for mismatches.
+func describeNode(node Node) string {
+	if name := node.NodeName(); name != "#text" {
+		return fmt.Sprintf("<%s>", name)
+	}
+	return fmt.Sprintf("text %q", node.TextContent())
+}
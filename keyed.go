@@ -0,0 +1,168 @@
+package vue
+
+import (
+	"golang.org/x/net/html"
+)
+
+// keyAttr is the attribute which identifies elements across renders, e.g. v-bind:key or :key.
+// The key is never rendered as an attribute of the element.
+const keyAttr = "key"
+
+// attrKey returns the key from the attributes, otherwise empty.
+func attrKey(attrs []html.Attribute) string {
+	for _, attr := range attrs {
+		if attr.Key == keyAttr {
+			return attr.Val
+		}
+	}
+	return ""
+}
+
+// hasKeys returns true if any child element of the node has a key.
+func hasKeys(node *html.Node) bool {
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.ElementNode && attrKey(child.Attr) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// renderKeyed renders the children of the virtual node by matching keys instead of positions.
+// Matching children are moved instead of recreated, the children which are not
+// part of the longest increasing subsequence of previous positions are moved.
+func (dst *vnode) renderKeyed(src *html.Node, subs subs) {
+	var olds []*vnode
+	keyed := make(map[string]int, 0)
+	for child := dst.firstChild; child != nil; child = child.nextSibling {
+		if child.key != "" {
+			keyed[child.key] = len(olds)
+		}
+		olds = append(olds, child)
+	}
+	used := make([]bool, len(olds))
+
+	// match finds the unused previous child by key, otherwise by order of unkeyed children.
+	match := func(key string, typ html.NodeType, data string) int {
+		if key != "" {
+			if i, ok := keyed[key]; ok && !used[i] && olds[i].typ == typ && olds[i].data == data {
+				return i
+			}
+			return -1
+		}
+		for i, old := range olds {
+			if !used[i] && old.key == "" && old.typ == typ && old.data == data {
+				return i
+			}
+		}
+		return -1
+	}
+
+	var news []*vnode
+	var sources []int
+	for srcChild := src.FirstChild; srcChild != nil; srcChild = srcChild.NextSibling {
+		key := ""
+		if srcChild.Type == html.ElementNode {
+			key = attrKey(srcChild.Attr)
+		}
+
+		var child *vnode
+		var i int
+		if subNode, ok := subs.elementVnode(srcChild); ok {
+			// Subcomponent instances are matched by key, the same instance is moved.
+			subNode.key = key
+			subNode.parentAttrs = srcChild.Attr
+			subNode.renderRootAttributes()
+			child, i = subNode, -1
+			for j, old := range olds {
+				if old == subNode && !used[j] {
+					i = j
+				}
+			}
+		} else if i = match(key, srcChild.Type, srcChild.Data); i < 0 {
+			child = createNode(dst.doc, srcChild, subs)
+		} else if srcChild.Type == html.ElementNode {
+			child = olds[i]
+			child.renderAttributes(srcChild.Attr)
+			child.render(srcChild, subs)
+		} else {
+			child = olds[i]
+			if child.data != srcChild.Data {
+				child.setText(srcChild.Data)
+			}
+		}
+		if i >= 0 {
+			used[i] = true
+		}
+		news = append(news, child)
+		sources = append(sources, i)
+	}
+
+	for i, old := range olds {
+		if !used[i] {
+			dst.node.RemoveChild(old.node)
+		}
+	}
+
+	// Children in the longest increasing subsequence keep their relative order, the rest are moved.
+	stable := make(map[int]struct{}, len(news))
+	for _, i := range longestIncreasing(sources) {
+		stable[i] = struct{}{}
+	}
+	var ref Node
+	for i := len(news) - 1; i >= 0; i-- {
+		if _, ok := stable[i]; !ok {
+			dst.node.InsertBefore(news[i].node, ref)
+		}
+		ref = news[i].node
+	}
+
+	dst.firstChild, dst.lastChild = nil, nil
+	for _, child := range news {
+		child.prevSibling, child.nextSibling = nil, nil
+		dst.adopt(child)
+	}
+}
+
+// longestIncreasing returns the indexes of the longest increasing subsequence of the values.
+// Negative values are never part of the subsequence.
+func longestIncreasing(values []int) []int {
+	// tails holds the index of the smallest tail value for every subsequence length.
+	var tails []int
+	prevs := make([]int, len(values))
+	for i, val := range values {
+		if val < 0 {
+			continue
+		}
+		lo, hi := 0, len(tails)
+		for lo < hi {
+			mid := (lo + hi) / 2
+			if values[tails[mid]] < val {
+				lo = mid + 1
+			} else {
+				hi = mid
+			}
+		}
+		if lo > 0 {
+			prevs[i] = tails[lo-1]
+		} else {
+			prevs[i] = -1
+		}
+		if lo == len(tails) {
+			tails = append(tails, i)
+		} else {
+			tails[lo] = i
+		}
+	}
+
+	seq := make([]int, len(tails))
+	for i, k := len(tails)-1, -1; i >= 0; i-- {
+		if k < 0 {
+			k = tails[len(tails)-1]
+		} else {
+			k = prevs[k]
+		}
+		seq[i] = k
+	}
+	return seq
+}
@@ -0,0 +1,186 @@
+package vue
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// loop is the parsed value of the vue for attribute.
+// The names are the item, then the key or index, then the index.
+// For example: (Todo, Index) in Todos, (Value, Key, Index) in Map, N in 10
+type loop struct {
+	names []string
+	src   string
+}
+
+// loopItem is an item of a loop with its key.
+// The key is the index of sequences, the key of maps or the name of the field of structs.
+type loopItem struct {
+	item alias
+	key  interface{}
+}
+
+// parseFor parses the value of the vue for attribute.
+// Either a name or up to three names in parentheses are followed by in, then the source expression.
+func parseFor(value string) (*loop, error) {
+	rest := strings.TrimSpace(value)
+	var names []string
+	if strings.HasPrefix(rest, "(") {
+		end := strings.Index(rest, ")")
+		if end < 0 {
+			return nil, fmt.Errorf("invalid loop: %s", value)
+		}
+		names = strings.Split(rest[1:end], ",")
+		rest = rest[end+1:]
+	} else {
+		end := strings.IndexFunc(rest, unicode.IsSpace)
+		if end < 0 {
+			return nil, fmt.Errorf("invalid loop: %s", value)
+		}
+		names = []string{rest[:end]}
+		rest = rest[end:]
+	}
+	if len(names) > 3 {
+		return nil, fmt.Errorf("invalid loop, too many names: %s", value)
+	}
+	for i, name := range names {
+		names[i] = strings.TrimSpace(name)
+		if !isIdent(names[i]) {
+			return nil, fmt.Errorf("invalid loop, name %q is not an identifier: %s", names[i], value)
+		}
+	}
+
+	// The keyword must be separated by spaces, e.g. Item in Inventory.
+	fields := strings.Fields(rest)
+	if len(fields) < 2 || fields[0] != "in" {
+		return nil, fmt.Errorf("invalid loop, expected in: %s", value)
+	}
+	src := strings.TrimSpace(strings.TrimSpace(rest)[len("in"):])
+	return &loop{names: names, src: src}, nil
+}
+
+// isIdent returns true if the name is an identifier.
+func isIdent(name string) bool {
+	for i, c := range name {
+		if c != '_' && !unicode.IsLetter(c) && (i == 0 || !unicode.IsDigit(c)) {
+			return false
+		}
+	}
+	return name != ""
+}
+
+// scope returns the scope of the item at the index, nested in the scope of the loop.
+func (loop *loop) scope(sc *scope, item loopItem, index int) *scope {
+	items := []alias{
+		item.item,
+		{value: reflect.ValueOf(item.key)},
+		{value: reflect.ValueOf(index)},
+	}
+	return sc.with(loop.names, items[:len(loop.names)])
+}
+
+// loopItems returns the items of the source of the loop.
+// Items of slices, arrays and structs of data fields are aliased by their paths, other items by their values.
+// Slices, arrays, maps ordered by key, exported fields of structs and ranges of integers from 1 are iterated.
+// Channels are drained of the buffered values, the received values are kept by the view model,
+// so later renders iterate the values received by previous renders followed by the values received since.
+// The values are kept as long as renders iterate the channel, see releaseReceived.
+func (vm *ViewModel) loopItems(src string, sc *scope) []loopItem {
+	x := vm.parse(src)
+	path, ok := vm.fieldPath(x, sc)
+	var rv reflect.Value
+	if ok {
+		if rv = vm.getValue(path); !rv.IsValid() {
+			panic(&FieldError{Field: path, Err: errUnknownField})
+		}
+	} else {
+		path = src
+		rv = reflect.ValueOf(vm.evalExpr(x, sc))
+	}
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		rv = rv.Elem()
+	}
+
+	// item returns the alias of the item by path if the source is a data field.
+	item := func(subPath string, value reflect.Value) alias {
+		if ok {
+			return alias{path: joinField(path, subPath)}
+		}
+		return alias{value: value}
+	}
+
+	var items []loopItem
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i, n := 0, rv.Len(); i < n; i++ {
+			items = append(items, loopItem{item: item("["+strconv.Itoa(i)+"]", rv.Index(i)), key: i})
+		}
+	case reflect.Map:
+		keys := rv.MapKeys()
+		sortValues(keys)
+		for _, key := range keys {
+			items = append(items, loopItem{item: alias{value: rv.MapIndex(key)}, key: key.Interface()})
+		}
+	case reflect.Struct:
+		typ := rv.Type()
+		for i, n := 0, rv.NumField(); i < n; i++ {
+			if field := typ.Field(i); field.PkgPath == "" {
+				items = append(items, loopItem{item: item(field.Name, rv.Field(i)), key: field.Name})
+			}
+		}
+	case reflect.Chan:
+		ch := rv.Interface()
+		vm.iterated[ch] = struct{}{}
+		for {
+			val, ok := rv.TryRecv()
+			if !ok {
+				break
+			}
+			vm.received[ch] = append(vm.received[ch], val)
+		}
+		for i, val := range vm.received[ch] {
+			items = append(items, loopItem{item: alias{value: val}, key: i})
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		for i, n := 0, int(rv.Int()); i < n; i++ {
+			items = append(items, loopItem{item: alias{value: reflect.ValueOf(i + 1)}, key: i})
+		}
+	default:
+		panic(&FieldError{Field: path, Err: errNotIterable})
+	}
+	return items
+}
+
+// releaseReceived releases the values received from the channels which were not iterated by the last render.
+func (vm *ViewModel) releaseReceived() {
+	for ch := range vm.received {
+		if _, ok := vm.iterated[ch]; !ok {
+			delete(vm.received, ch)
+		}
+	}
+	vm.iterated = make(map[interface{}]struct{}, len(vm.received))
+}
+
+// sortValues sorts the keys of a map to iterate in a deterministic order.
+// Numbers and strings are ordered by value, other keys by their formatted values.
+func sortValues(keys []reflect.Value) {
+	sort.Slice(keys, func(i, j int) bool {
+		x, y := keys[i], keys[j]
+		switch x.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return x.Int() < y.Int()
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			return x.Uint() < y.Uint()
+		case reflect.Float32, reflect.Float64:
+			return x.Float() < y.Float()
+		case reflect.String:
+			return x.String() < y.String()
+		default:
+			return fmt.Sprint(x.Interface()) < fmt.Sprint(y.Interface())
+		}
+	})
+}
@@ -80,6 +80,74 @@ func getField(v reflect.Value, path string) reflect.Value {
 	return reflect.Value{}
 }
 
+// GetFieldType returns the type of the field at the path without a value, e.g. to validate paths.
+// Paths below an interface type cannot be resolved without a value, so the interface type is returned.
+func GetFieldType(t reflect.Type, path string) (reflect.Type, bool) {
+	defer func() {
+		if err, ok := recover().(error); ok && strings.HasPrefix(err.Error(), errMapPrefix) {
+			panic(err)
+		}
+	}()
+
+	return getFieldType(t, path)
+}
+func getFieldType(t reflect.Type, path string) (reflect.Type, bool) {
+	t = deref(t)
+	if path == "" || t.Kind() == reflect.Interface {
+		return t, true
+	}
+
+	if ob, cb := strings.Index(path, "["), strings.Index(path, "]"); cb > ob {
+		parent, ok := getFieldType(t, path[:ob])
+		if !ok {
+			return nil, false
+		}
+		parent = deref(parent)
+		if parent.Kind() == reflect.Interface {
+			return parent, true
+		}
+		subPath := strings.TrimPrefix(path[cb+1:], ".")
+		key := path[ob+1 : cb]
+		if _, err := strconv.Unquote(key); err == nil && parent.Kind() == reflect.Map {
+			return getFieldType(parent.Elem(), subPath)
+		}
+		if _, err := strconv.Atoi(key); err == nil {
+			if k := parent.Kind(); k == reflect.Slice || k == reflect.Array {
+				return getFieldType(parent.Elem(), subPath)
+			}
+		}
+		return nil, false
+	}
+
+	if t.Kind() != reflect.Struct {
+		return nil, false
+	}
+	m := getMapping(t)
+	if index, ok := m.Paths[path]; ok {
+		return t.FieldByIndex(index).Type, true
+	}
+	for _, prefix := range m.Loops {
+		if !strings.HasPrefix(path, prefix) {
+			continue
+		}
+		path = strings.TrimPrefix(path, prefix)
+		path = strings.TrimPrefix(path, ".")
+		return getFieldType(t.FieldByIndex(m.Paths[prefix]).Type, path)
+	}
+	// Paths below interface fields are only known with a value.
+	for i := range path {
+		if path[i] != '.' {
+			continue
+		}
+		if index, ok := m.Paths[path[:i]]; ok {
+			if field := deref(t.FieldByIndex(index).Type); field.Kind() == reflect.Interface {
+				return field, true
+			}
+		}
+	}
+	return nil, false
+}
+
 // -- helpers & utilities --
 type fieldInfo struct {
 	Type   reflect.Type
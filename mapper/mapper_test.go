@@ -242,3 +242,37 @@ func TestRecursive(t *testing.T) {
 		t.Run(c.path, c.Run)
 	}
 }
+
+func TestFieldTypes(t *testing.T) {
+	type data struct {
+		Basic BasicStruct
+		Slice []*BasicStruct
+		Map   map[string]BasicStruct
+		Any   interface{}
+	}
+
+	root := reflect.TypeOf(&data{})
+	cases := []struct {
+		path string
+		want reflect.Type
+	}{
+		{path: "Basic", want: reflect.TypeOf(BasicStruct{})},
+		{path: "Basic.String", want: reflect.TypeOf("")},
+		{path: "Slice", want: reflect.TypeOf([]*BasicStruct{})},
+		{path: "Slice[0].Int", want: reflect.TypeOf(0)},
+		{path: `Map["key"].Bool`, want: reflect.TypeOf(true)},
+		{path: "Any.Unknown", want: reflect.TypeOf((*interface{})(nil)).Elem()},
+
+		{path: "Basic.priv"},
+		{path: "Basic.String.Bad"},
+		{path: "Slice[x]"},
+		{path: "BadValue"},
+	}
+
+	for _, c := range cases {
+		got, ok := GetFieldType(root, c.path)
+		if ok != (c.want != nil) || got != c.want {
+			t.Errorf("getting type of %s returned %v, expected %v", c.path, got, c.want)
+		}
+	}
+}
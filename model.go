@@ -0,0 +1,409 @@
+package vue
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// modelAttr is the attribute of a select element with the field path of its model.
+// The attribute is removed once the options of the select element are selected.
+const modelAttr = "vue-model"
+
+// updateEvent is the prefix of events emitted by subcomponents to update the data field bound to a prop.
+// For example: v-model="Date" binds the Value prop, then update:Value sets Date.
+const updateEvent = "update:"
+
+// modelProp is the default prop of subcomponents bound by the vue model attribute.
+const modelProp = "Value"
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// modelKind is the kind of element bound to a model.
+// The kind determines how the value of the field is rendered and read back.
+type modelKind int
+
+const (
+	// modelText is an input or textarea bound to the value, the default kind.
+	modelText modelKind = iota
+	// modelCheckbox is a checkbox bound to a bool, or to a slice of the values of its checkboxes.
+	modelCheckbox
+	// modelRadio is a radio button bound to the value of the checked radio button of its group.
+	modelRadio
+	// modelSelect is a select element bound to the value of its selected option.
+	modelSelect
+	// modelSelectMultiple is a multiple select element bound to a slice of the values of its selected options.
+	modelSelectMultiple
+)
+
+// modelKindOf returns the kind of the element of the tag, input type and multiple attribute.
+func modelKindOf(tag, inputType string, multiple bool) modelKind {
+	switch tag {
+	case "select":
+		if multiple {
+			return modelSelectMultiple
+		}
+		return modelSelect
+	case "input":
+		switch strings.ToLower(inputType) {
+		case "checkbox":
+			return modelCheckbox
+		case "radio":
+			return modelRadio
+		}
+	}
+	return modelText
+}
+
+// nodeModelKind returns the kind of the html element.
+func nodeModelKind(node *html.Node) modelKind {
+	inputType, _ := attrValue(node, "type")
+	return modelKindOf(node.Data, inputType, hasAttr(node, "multiple"))
+}
+
+// splitModel splits the argument of the vue model attribute into the prop and the modifiers.
+// The prop only binds subcomponents, it defaults to Value.
+// For example: title.trim -> Title, .trim or .lazy -> Value, .lazy
+func splitModel(arg string) (string, string) {
+	prop, modifiers := arg, ""
+	if i := strings.Index(arg, "."); i >= 0 {
+		prop, modifiers = arg[:i], arg[i:]
+	}
+	if prop == "" {
+		return modelProp, modifiers
+	}
+	return strings.Title(prop), modifiers
+}
+
+// setModel sets the data field bound to a prop of a subcomponent to the first argument of its update event.
+// Numbers are converted to the type of the field, e.g. int to int64.
+func (vm *ViewModel) setModel(field string, args []interface{}) {
+	if len(args) == 0 {
+		panic(&FieldError{Field: field, Err: errors.New("update event without a value")})
+	}
+	fieldVal := vm.lookup(field)
+	if !fieldVal.IsValid() {
+		panic(&FieldError{Field: field, Err: errUnknownField})
+	}
+	value, err := convertArg(args[0], fieldVal.Type())
+	if err != nil {
+		panic(&FieldError{Field: field, Err: err})
+	}
+	vm.set(field, value.Interface())
+}
+
+// renderModel renders the value of the field by the element of the kind.
+// Text elements render the value, checkboxes and radio buttons render if they are checked.
+// The options of select elements are selected once executed, see selectOptions.
+func renderModel(node *html.Node, kind modelKind, value reflect.Value) error {
+	switch kind {
+	case modelCheckbox:
+		checked, err := modelChecked(node, value)
+		if checked {
+			node.Attr = append(node.Attr, html.Attribute{Key: "checked"})
+		}
+		return err
+	case modelRadio:
+		text, err := formatModel(value)
+		if val, _ := attrValue(node, "value"); err == nil && text == val {
+			node.Attr = append(node.Attr, html.Attribute{Key: "checked"})
+		}
+		return err
+	case modelSelect, modelSelectMultiple:
+		_, err := modelValues(value, kind)
+		return err
+	default:
+		text, err := formatModel(value)
+		setAttr(node, "value", text)
+		return err
+	}
+}
+
+// modelChecked returns true if the checkbox is checked by the value of the field.
+// Values of bool fields check the checkbox, slice fields check the checkbox if they contain its value.
+func modelChecked(node *html.Node, value reflect.Value) (bool, error) {
+	value = reflect.Indirect(value)
+	if value.Kind() == reflect.Interface {
+		value = value.Elem()
+	}
+	switch value.Kind() {
+	case reflect.Invalid:
+		return false, nil
+	case reflect.Bool:
+		return value.Bool(), nil
+	case reflect.Slice, reflect.Array:
+		values, err := modelValues(value, modelSelectMultiple)
+		val, _ := attrValue(node, "value")
+		return values[val], err
+	default:
+		return false, errModelType
+	}
+}
+
+// modelValues returns the set of formatted values of the field.
+// The values of multiple select elements are the items of slices, otherwise the value itself.
+func modelValues(value reflect.Value, kind modelKind) (map[string]bool, error) {
+	if value.Kind() == reflect.Interface {
+		value = value.Elem()
+	}
+	if kind != modelSelectMultiple {
+		text, err := formatModel(value)
+		return map[string]bool{text: true}, err
+	}
+	switch value.Kind() {
+	case reflect.Invalid:
+		return nil, nil
+	case reflect.Slice, reflect.Array:
+	default:
+		return nil, errModelType
+	}
+	values := make(map[string]bool, value.Len())
+	for i, n := 0, value.Len(); i < n; i++ {
+		text, err := formatModel(value.Index(i))
+		if err != nil {
+			return nil, err
+		}
+		values[text] = true
+	}
+	return values, nil
+}
+
+// selectOptions selects the options of the select element by the value of its model.
+func (vm *ViewModel) selectOptions(node *html.Node) {
+	i := attrIndex(node, modelAttr)
+	if i < 0 {
+		return
+	}
+	field := node.Attr[i].Val
+	node.Attr = append(node.Attr[:i], node.Attr[i+1:]...)
+
+	defer locate(node, vModel)
+	values, err := modelValues(vm.getValue(field), nodeModelKind(node))
+	if err != nil {
+		panic(&FieldError{Field: field, Err: err})
+	}
+
+	var walk func(*html.Node)
+	walk = func(node *html.Node) {
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			switch child.DataAtom {
+			case atom.Option:
+				val, ok := attrValue(child, "value")
+				if !ok {
+					val = strings.TrimSpace(textContent(child))
+				}
+				if values[val] {
+					setAttr(child, "selected", "")
+				}
+			case atom.Optgroup:
+				walk(child)
+			}
+		}
+	}
+	walk(node)
+}
+
+// formatModel formats the value of the field to be rendered by the element.
+// Values implementing encoding.TextMarshaler are marshaled, other values must be of basic kinds.
+func formatModel(value reflect.Value) (string, error) {
+	switch value.Kind() {
+	case reflect.Invalid:
+		return "", nil
+	case reflect.Interface, reflect.Ptr:
+		if value.IsNil() {
+			return "", nil
+		}
+	}
+	if marshaler, ok := value.Interface().(encoding.TextMarshaler); ok {
+		text, err := marshaler.MarshalText()
+		return string(text), err
+	}
+	value = reflect.Indirect(value)
+	if value.Kind() == reflect.Interface {
+		value = value.Elem()
+	}
+	switch value.Kind() {
+	case reflect.String:
+		return value.String(), nil
+	case reflect.Bool, reflect.Float32, reflect.Float64:
+		return fmt.Sprint(value.Interface()), nil
+	}
+	if isNumeric(value.Kind()) {
+		return fmt.Sprint(value.Interface()), nil
+	}
+	return "", errModelType
+}
+
+// parseModel parses the text of the element into a value of the type of the field.
+// Values implementing encoding.TextUnmarshaler are unmarshaled, numbers and bools are parsed.
+// The trim modifier trims whitespace, the number modifier parses numbers into fields of interface type.
+// Fields of other types must be numbers with the number modifier, see isNumberModel.
+func parseModel(text string, typ reflect.Type, modifiers map[string]struct{}) (reflect.Value, error) {
+	if _, ok := modifiers["Trim"]; ok {
+		text = strings.TrimSpace(text)
+	}
+	if reflect.PtrTo(typ).Implements(textUnmarshalerType) {
+		ptr := reflect.New(typ)
+		err := ptr.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(text))
+		return ptr.Elem(), err
+	}
+
+	value := reflect.New(typ).Elem()
+	switch kind := typ.Kind(); {
+	case kind == reflect.String:
+		value.SetString(text)
+	case kind == reflect.Bool:
+		b, err := strconv.ParseBool(text)
+		if err != nil {
+			return value, err
+		}
+		value.SetBool(b)
+	case reflect.Int <= kind && kind <= reflect.Int64:
+		n, err := strconv.ParseInt(strings.TrimSpace(text), 10, typ.Bits())
+		if err != nil {
+			return value, err
+		}
+		value.SetInt(n)
+	case reflect.Uint <= kind && kind <= reflect.Uintptr:
+		n, err := strconv.ParseUint(strings.TrimSpace(text), 10, typ.Bits())
+		if err != nil {
+			return value, err
+		}
+		value.SetUint(n)
+	case kind == reflect.Float32 || kind == reflect.Float64:
+		f, err := strconv.ParseFloat(strings.TrimSpace(text), typ.Bits())
+		if err != nil {
+			return value, err
+		}
+		value.SetFloat(f)
+	case kind == reflect.Interface && typ.NumMethod() == 0:
+		// Texts which are not numbers remain texts, like the number modifier of vue.
+		if _, ok := modifiers["Number"]; ok {
+			if f, err := strconv.ParseFloat(strings.TrimSpace(text), 64); err == nil {
+				return reflect.ValueOf(f), nil
+			}
+		}
+		return reflect.ValueOf(text), nil
+	default:
+		return value, errModelType
+	}
+	return value, nil
+}
+
+// parseChecked returns the value of the field from the checkbox.
+// Bool fields are set to the checked state, slice fields add or remove the value of the checkbox.
+func parseChecked(field reflect.Value, text string, checked bool, modifiers map[string]struct{}) (reflect.Value, error) {
+	typ := field.Type()
+	switch typ.Kind() {
+	case reflect.Bool:
+		return reflect.ValueOf(checked).Convert(typ), nil
+	case reflect.Interface:
+		return reflect.ValueOf(checked), nil
+	case reflect.Slice:
+	default:
+		return reflect.Value{}, errModelType
+	}
+
+	item, err := parseModel(text, typ.Elem(), modifiers)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	values := reflect.MakeSlice(typ, 0, field.Len()+1)
+	for i, n := 0, field.Len(); i < n; i++ {
+		if !reflect.DeepEqual(field.Index(i).Interface(), item.Interface()) {
+			values = reflect.Append(values, field.Index(i))
+		}
+	}
+	if checked {
+		values = reflect.Append(values, item)
+	}
+	return values, nil
+}
+
+// parseSelected returns the value of the field from the selected options of the multiple select element.
+func parseSelected(texts []string, typ reflect.Type, modifiers map[string]struct{}) (reflect.Value, error) {
+	if typ.Kind() != reflect.Slice {
+		return reflect.Value{}, errModelType
+	}
+	values := reflect.MakeSlice(typ, 0, len(texts))
+	for _, text := range texts {
+		item, err := parseModel(text, typ.Elem(), modifiers)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		values = reflect.Append(values, item)
+	}
+	return values, nil
+}
+
+// selectedValues returns the values of the selected options of the select element.
+func selectedValues(node Node) []string {
+	var values []string
+	for _, child := range node.ChildNodes() {
+		switch child.NodeName() {
+		case "option":
+			if selected, _ := child.Property("selected").(bool); selected {
+				values = append(values, nodeValue(child))
+			}
+		case "optgroup":
+			values = append(values, selectedValues(child)...)
+		}
+	}
+	return values
+}
+
+// nodeValue returns the value of the element, the text of options without a value.
+func nodeValue(node Node) string {
+	if val, ok := node.Property("value").(string); ok {
+		return val
+	}
+	if node.NodeName() == "option" {
+		return strings.TrimSpace(node.TextContent())
+	}
+	return ""
+}
+
+// attrIndex returns the index of the attribute of the node, or -1 if not found.
+func attrIndex(node *html.Node, key string) int {
+	for i, attr := range node.Attr {
+		if attr.Key == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// attrValue returns the value of the attribute of the node.
+func attrValue(node *html.Node, key string) (string, bool) {
+	if i := attrIndex(node, key); i >= 0 {
+		return node.Attr[i].Val, true
+	}
+	return "", false
+}
+
+// setAttr sets the attribute of the node, replacing the previous value if any.
+func setAttr(node *html.Node, key, val string) {
+	if i := attrIndex(node, key); i >= 0 {
+		node.Attr[i].Val = val
+		return
+	}
+	node.Attr = append(node.Attr, html.Attribute{Key: key, Val: val})
+}
+
+// textContent returns the text of the node and its children.
+func textContent(node *html.Node) string {
+	if node.Type == html.TextNode {
+		return node.Data
+	}
+	sb := &strings.Builder{}
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		sb.WriteString(textContent(child))
+	}
+	return sb.String()
+}
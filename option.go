@@ -1,6 +1,7 @@
 package vue
 
 import (
+	"log"
 	"reflect"
 	"runtime"
 	"strings"
@@ -17,8 +18,34 @@ func El(el string) Option {
 	}
 }
 
+// Backend is the dom backend option for components.
+// The root element of a component is query selected from the document.
+// Without a backend the browser document is used, or an empty in-memory document without a browser.
+func Backend(doc Document) Option {
+	return func(comp *Comp) {
+		comp.doc = doc
+	}
+}
+
+// Hydrate is the hydrate option for components.
+// The server rendered children of the root element are adopted on the first render instead of recreated.
+// The mismatch function is called for every difference between the server and client output, which is then patched.
+// Without a mismatch function the differences are logged.
+func Hydrate(mismatch func(msg string)) Option {
+	return func(comp *Comp) {
+		if mismatch == nil {
+			mismatch = func(msg string) {
+				log.Printf("vue: hydration mismatch: %s", msg)
+			}
+		}
+		comp.hydrate = true
+		comp.mismatch = mismatch
+	}
+}
+
 // Template is the template option for components.
-// The template uses the mustache syntax for rendering.
+// Text is interpolated with expressions between double braces, e.g. {{ Todo.Text }},
+// and vue attributes are bound to expressions, see the expr package.
 // The template must have a single root element.
 func Template(tmpl string) Option {
 	return func(comp *Comp) {
@@ -53,6 +80,7 @@ func Method(name string, function interface{}) Option {
 // The given functions are registered as methods for the component.
 // The functions are required to accept context and allows optional arguments.
 // For example: func(vctx vue.Context) or func(vctx vue.Context, a1 Arg1, ..., ak ArgK)
+// Methods of event handlers may accept the event, e.g. func(vctx vue.Context, event vue.Event)
 func Methods(functions ...interface{}) Option {
 	return func(comp *Comp) {
 		for _, function := range functions {
@@ -67,6 +95,8 @@ func Methods(functions ...interface{}) Option {
 // The given name and function is registered as a computed property for the component.
 // The function is required to accept context and return a value.
 // For example: func(vctx vue.Context) Type
+// The fields read by Get are tracked, so the value is cached until they change.
+// If the function reads no fields by Get, or reads Data, the value is computed again by every render.
 func Computed(name string, function interface{}) Option {
 	return func(comp *Comp) {
 		fn := reflect.ValueOf(function)
@@ -100,6 +130,58 @@ func Watch(field string, function interface{}) Option {
 	}
 }
 
+// Created is the created hook option for components.
+// The function is called once the data of the component is created, before the first render.
+func Created(function func(vctx Context)) Option {
+	return func(comp *Comp) {
+		comp.hooks[hookCreated] = function
+	}
+}
+
+// Mounted is the mounted hook option for components.
+// The function is called once the component is first rendered into the dom.
+// Subcomponents are mounted before their parent.
+func Mounted(function func(vctx Context)) Option {
+	return func(comp *Comp) {
+		comp.hooks[hookMounted] = function
+	}
+}
+
+// Updated is the updated hook option for components.
+// The function is called after every render of the component which patched the dom.
+func Updated(function func(vctx Context)) Option {
+	return func(comp *Comp) {
+		comp.hooks[hookUpdated] = function
+	}
+}
+
+// BeforeUnmount is the before unmount hook option for components.
+// The function is called before the component is removed, while it is still in the dom.
+func BeforeUnmount(function func(vctx Context)) Option {
+	return func(comp *Comp) {
+		comp.hooks[hookBeforeUnmount] = function
+	}
+}
+
+// Unmounted is the unmounted hook option for components.
+// The function is called once the component is removed from the dom.
+func Unmounted(function func(vctx Context)) Option {
+	return func(comp *Comp) {
+		comp.hooks[hookUnmounted] = function
+	}
+}
+
+// ErrorHandler is the error handler option for components.
+// Errors while rendering, or calling the functions of the component, are passed to the handler
+// instead of panicking, e.g. *FieldError and *TemplateError.
+// Errors of subcomponents without a handler propagate to the nearest parent with a handler.
+// Without any handler errors are logged.
+func ErrorHandler(handler func(vctx Context, err error)) Option {
+	return func(comp *Comp) {
+		comp.handler = handler
+	}
+}
+
 // Sub is the subcomponent option for components.
 func Sub(element string, sub *Comp) Option {
 	return func(comp *Comp) {
@@ -117,6 +199,40 @@ func Props(props ...string) Option {
 	}
 }
 
+// PropsOf is the typed props option for subcomponents.
+// The exported fields of the struct are the props, e.g. PropsOf(TodoProps{}).
+// Tags of the fields declare defaults, required props and validator methods of the struct, for example:
+// Title string `default:"untitled" validator:"ValidTitle"`, Todo Todo `required:"true"`.
+// Props passed by the parent are converted to the types of the fields,
+// props of other types, missing required props and failed validators are passed to the error handler.
+// The typed props are returned by the props of the context.
+func PropsOf(props interface{}) Option {
+	return func(sub *Comp) {
+		typ := reflect.TypeOf(props)
+		if typ != nil && typ.Kind() == reflect.Ptr {
+			typ = typ.Elem()
+		}
+		sub.propsType = typ
+	}
+}
+
+// Emits is the emits option for subcomponents.
+// The subcomponent declares the events it emits, emitting other events is an error.
+// Update events of props bound by vue model attributes are always declared.
+// Vue on attributes of the element of the subcomponent listen to the declared events of the instance,
+// other events are listened to on the root element of the instance.
+// Without the emits option, vue on attributes listen to both.
+func Emits(events ...string) Option {
+	return func(sub *Comp) {
+		if sub.emits == nil {
+			sub.emits = make(map[string]struct{}, len(events))
+		}
+		for _, event := range events {
+			sub.emits[strings.ToLower(event)] = struct{}{}
+		}
+	}
+}
+
 // funcName returns the name of the given function.
 func funcName(function reflect.Value) string {
 	name := runtime.FuncForPC(function.Pointer()).Name()
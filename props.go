@@ -0,0 +1,175 @@
+package vue
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+)
+
+// errorType is the type of the error returned by validators.
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// propField is a prop declared by an exported field of the props struct of a subcomponent.
+// The tags of the field declare the default value, whether the prop is required and the validator method.
+// For example: Title string `default:"untitled" validator:"ValidTitle"`, Todo Todo `required:"true"`
+type propField struct {
+	name      string
+	index     int
+	typ       reflect.Type
+	def       reflect.Value
+	required  bool
+	validator reflect.Value
+}
+
+// validateProps validates the props struct of the subcomponent, then declares its fields as props.
+// Defaults are parsed for fields of basic kinds. Validators are methods of the props struct
+// which receive the value of the prop, e.g. func (props TodoProps) ValidTitle(title string) error.
+func (comp *Comp) validateProps() Diagnostics {
+	typ := comp.propsType
+	if typ == nil {
+		return nil
+	}
+	if typ.Kind() != reflect.Struct {
+		return Diagnostics{fmt.Errorf("vue: props: expected a struct, got %v", typ)}
+	}
+
+	var diags Diagnostics
+	comp.propFields = nil
+	for i, n := 0, typ.NumField(); i < n; i++ {
+		field := typ.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		prop, err := parsePropField(typ, field)
+		if err != nil {
+			diags = append(diags, fmt.Errorf("vue: prop %s: %v", field.Name, err))
+			continue
+		}
+		prop.index = i
+		comp.props[field.Name] = struct{}{}
+		comp.propFields = append(comp.propFields, prop)
+	}
+	return diags
+}
+
+// parsePropField parses the tags of the field of the props struct.
+func parsePropField(typ reflect.Type, field reflect.StructField) (*propField, error) {
+	prop := &propField{name: field.Name, typ: field.Type}
+	if tag, ok := field.Tag.Lookup("default"); ok {
+		def, err := parseDefault(tag, field.Type)
+		if err != nil {
+			return nil, err
+		}
+		prop.def = def
+	}
+	if tag, ok := field.Tag.Lookup("required"); ok {
+		required, err := strconv.ParseBool(tag)
+		if err != nil {
+			return nil, fmt.Errorf("invalid required tag: %s", tag)
+		}
+		prop.required = required
+	}
+	if tag, ok := field.Tag.Lookup("validator"); ok {
+		method, ok := typ.MethodByName(tag)
+		if !ok {
+			return nil, fmt.Errorf("unknown validator: %s", tag)
+		}
+		if mt := method.Type; mt.NumIn() != 2 || mt.In(1) != field.Type || mt.NumOut() != 1 || mt.Out(0) != errorType {
+			return nil, fmt.Errorf("validator %s: expected func(%v) error, got %v", tag, field.Type, mt)
+		}
+		prop.validator = method.Func
+	}
+	return prop, nil
+}
+
+// parseDefault parses the default tag into a value of the type.
+// Strings, booleans and numbers are supported.
+func parseDefault(tag string, typ reflect.Type) (reflect.Value, error) {
+	rv := reflect.New(typ).Elem()
+	var err error
+	switch typ.Kind() {
+	case reflect.String:
+		rv.SetString(tag)
+	case reflect.Bool:
+		var b bool
+		b, err = strconv.ParseBool(tag)
+		rv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var i int64
+		i, err = strconv.ParseInt(tag, 10, typ.Bits())
+		rv.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		var u uint64
+		u, err = strconv.ParseUint(tag, 10, typ.Bits())
+		rv.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		var f float64
+		f, err = strconv.ParseFloat(tag, typ.Bits())
+		rv.SetFloat(f)
+	default:
+		return reflect.Value{}, fmt.Errorf("default of type %v is not supported", typ)
+	}
+	if err != nil {
+		return reflect.Value{}, fmt.Errorf("invalid default: %s", tag)
+	}
+	return rv, nil
+}
+
+// resolveProps converts the props passed by the parent to the types of the props struct.
+// Props which are not passed are set to their defaults, otherwise to zero values.
+// Props of other types and missing required props are zero values, they return errors like failed validators,
+// so the instance is still rendered.
+func (comp *Comp) resolveProps(element string, props map[string]interface{}) (map[string]interface{}, []error) {
+	if comp.propsType == nil {
+		return props, nil
+	}
+	if props == nil {
+		props = make(map[string]interface{}, len(comp.propFields))
+	}
+
+	var errs []error
+	report := func(prop *propField, err error) {
+		err = fmt.Errorf("prop %s of subcomponent %s: %v", prop.name, element, err)
+		errs = append(errs, &TemplateError{Err: err})
+	}
+	for _, prop := range comp.propFields {
+		rv := prop.def
+		if !rv.IsValid() {
+			rv = reflect.Zero(prop.typ)
+		}
+		if val, ok := props[prop.name]; ok {
+			if converted, err := convertArg(val, prop.typ); err != nil {
+				report(prop, err)
+			} else {
+				rv = converted
+			}
+		} else if prop.required {
+			report(prop, errors.New("missing required prop"))
+		}
+		props[prop.name] = rv.Interface()
+	}
+
+	value := comp.propsValue(props)
+	for _, prop := range comp.propFields {
+		if !prop.validator.IsValid() {
+			continue
+		}
+		out := prop.validator.Call([]reflect.Value{value, value.Field(prop.index)})
+		if err, _ := out[0].Interface().(error); err != nil {
+			report(prop, err)
+		}
+	}
+	return props, errs
+}
+
+// propsValue returns the props as a value of the props struct.
+func (comp *Comp) propsValue(props map[string]interface{}) reflect.Value {
+	rv := reflect.New(comp.propsType).Elem()
+	for _, prop := range comp.propFields {
+		if val, ok := props[prop.name]; ok && val != nil {
+			rv.Field(prop.index).Set(reflect.ValueOf(val))
+		}
+	}
+	return rv
+}
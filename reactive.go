@@ -0,0 +1,170 @@
+package vue
+
+import (
+	"reflect"
+	"strings"
+)
+
+// computed is the cached state of a computed property.
+// The data paths read through the context while evaluating are recorded as dependencies.
+// A computed property is untracked if it has no dependencies or read the data directly,
+// then it is evaluated again by every render.
+type computed struct {
+	name       string
+	value      interface{}
+	deps       map[string]dep
+	dirty      bool
+	untracked  bool
+	evaluated  bool
+	evaluating bool
+}
+
+// dep is a dependency of a computed property on a data path.
+// Values of scalar kinds are recorded, other kinds may be mutated in place.
+type dep struct {
+	value  interface{}
+	scalar bool
+}
+
+// newComputeds creates the dirty computed states of the component.
+func newComputeds(functions map[string]reflect.Value) map[string]*computed {
+	computeds := make(map[string]*computed, len(functions))
+	for name := range functions {
+		computeds[name] = &computed{name: name, dirty: true}
+	}
+	return computeds
+}
+
+// track records the value read from the data path as a dependency of the computed being evaluated.
+func (vm *ViewModel) track(field string, rv reflect.Value) {
+	c := vm.tracking
+	if c == nil {
+		return
+	}
+	if isScalar(rv) {
+		c.deps[field] = dep{value: rv.Interface(), scalar: true}
+	} else if !rv.IsValid() {
+		c.deps[field] = dep{scalar: true}
+	} else {
+		c.deps[field] = dep{}
+	}
+}
+
+// computedValue returns the value of the computed property, evaluating it if dirty.
+func (vm *ViewModel) computedValue(name string) (interface{}, bool) {
+	c, ok := vm.computeds[name]
+	if !ok {
+		return nil, false
+	}
+	if (c.dirty || c.untracked) && !c.evaluating {
+		vm.evaluate(c)
+	}
+	return c.value, true
+}
+
+// evaluate evaluates the computed property while tracking its dependencies.
+// The watcher of the computed property is called if the value changed.
+func (vm *ViewModel) evaluate(c *computed) {
+	value := func() interface{} {
+		prev := vm.tracking
+		vm.tracking = c
+		c.deps = make(map[string]dep, len(c.deps))
+		c.untracked = false
+		c.evaluating = true
+		// The function may panic, e.g. with a field error passed to the error handler.
+		defer func() {
+			c.evaluating = false
+			c.untracked = c.untracked || len(c.deps) == 0
+			vm.tracking = prev
+		}()
+
+		function := vm.comp.computed[c.name]
+		return function.Call([]reflect.Value{reflect.ValueOf(vm.ctx)})[0].Interface()
+	}()
+
+	oldVal, evaluated := c.value, c.evaluated
+	c.value, c.dirty, c.evaluated = value, false, true
+	vm.cache[c.name] = value
+
+	watcher, ok := vm.comp.watchers[c.name]
+	if !ok || !evaluated || reflect.DeepEqual(oldVal, value) {
+		return
+	}
+	watcher.Call([]reflect.Value{
+		reflect.ValueOf(vm.ctx),
+		reflect.ValueOf(value),
+		reflect.ValueOf(oldVal),
+	})
+}
+
+// invalidate marks the computed properties which depend on the data path as dirty.
+// Computed properties which depend on dirty computed properties are also marked as dirty.
+func (vm *ViewModel) invalidate(field string) {
+	for _, c := range vm.computeds {
+		if c.dirty {
+			continue
+		}
+		for path := range c.deps {
+			if overlaps(path, field) {
+				c.dirty = true
+				vm.invalidate(c.name)
+				break
+			}
+		}
+	}
+}
+
+// invalidateMutated marks the computed properties as dirty if their dependencies may have been mutated,
+// e.g. by a method, a watcher or new props.
+// Scalar values are compared, other values are always considered mutated since they may be mutated in place.
+func (vm *ViewModel) invalidateMutated() {
+	for _, c := range vm.computeds {
+		if c.dirty {
+			continue
+		}
+		for path, d := range c.deps {
+			if topLevel, _ := splitField(path); vm.computeds[topLevel] != nil {
+				// Computed dependencies are invalidated through their own dependencies.
+				continue
+			}
+			if !d.scalar || !reflect.DeepEqual(d.value, valueInterface(vm.lookup(path))) {
+				c.dirty = true
+				vm.invalidate(c.name)
+				break
+			}
+		}
+	}
+}
+
+// overlaps returns true if either data path is the other or a parent of the other.
+// For example: Todos overlaps Todos[0].Text
+func overlaps(a, b string) bool {
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	if !strings.HasPrefix(b, a) {
+		return false
+	}
+	return len(a) == len(b) || b[len(a)] == '.' || b[len(a)] == '['
+}
+
+// isScalar returns true if the value is of a scalar kind which is compared by value.
+func isScalar(rv reflect.Value) bool {
+	switch rv.Kind() {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128:
+		return rv.CanInterface()
+	default:
+		return false
+	}
+}
+
+// valueInterface returns the interface of the value or nil if invalid.
+func valueInterface(rv reflect.Value) interface{} {
+	if !rv.IsValid() || !rv.CanInterface() {
+		return nil
+	}
+	return rv.Interface()
+}
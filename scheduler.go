@@ -0,0 +1,208 @@
+package vue
+
+import (
+	"bytes"
+	"runtime"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+// scheduler is the update loop of an app which batches the renders of dirty view models.
+// Renders are flushed once asynchronously, followed by the next tick callbacks.
+// All state mutations and renders of the app are serialized by locking the update loop.
+// The loop is locked by a semaphore, so callbacks which would lock it again are deferred instead of blocking.
+// The goroutine which locked the loop is its owner, public methods called by the owner run directly.
+type scheduler struct {
+	sem       chan struct{}
+	owner     int64
+	mutex     sync.Mutex
+	deferred  []func()
+	doc       Document
+	queue     []*ViewModel
+	hooks     []func()
+	ticks     []func()
+	afters    []func()
+	scheduled bool
+}
+
+// newScheduler creates a new scheduler which schedules flushes with the document.
+func newScheduler(doc Document) *scheduler {
+	return &scheduler{sem: make(chan struct{}, 1), doc: doc}
+}
+
+// lock locks the update loop.
+func (sched *scheduler) lock() {
+	sched.sem <- struct{}{}
+	atomic.StoreInt64(&sched.owner, goid())
+}
+
+// tryLock locks the update loop unless it is already locked.
+func (sched *scheduler) tryLock() bool {
+	select {
+	case sched.sem <- struct{}{}:
+		atomic.StoreInt64(&sched.owner, goid())
+		return true
+	default:
+		return false
+	}
+}
+
+// enter locks the update loop unless the current goroutine already owns it, e.g. a method of the component
+// calling the view model. It returns the function which leaves the loop.
+func (sched *scheduler) enter() func() {
+	if atomic.LoadInt64(&sched.owner) == goid() {
+		return func() {}
+	}
+	sched.lock()
+	return sched.unlock
+}
+
+// unlock calls the deferred callbacks, then unlocks the update loop.
+// Callbacks deferred after the callbacks are called, but before the loop is unlocked, are called by locking again.
+func (sched *scheduler) unlock() {
+	for {
+		for fn := sched.nextDeferred(); fn != nil; fn = sched.nextDeferred() {
+			fn()
+		}
+		atomic.StoreInt64(&sched.owner, 0)
+		<-sched.sem
+		if !sched.hasDeferred() || !sched.tryLock() {
+			return
+		}
+	}
+}
+
+// call calls the callback on the update loop, e.g. an event listener.
+// If the loop is already locked, the callback is deferred until the loop is unlocked.
+// For example, events dispatched synchronously by hooks, next tick callbacks or renders are handled after them.
+func (sched *scheduler) call(fn func()) {
+	sched.mutex.Lock()
+	sched.deferred = append(sched.deferred, fn)
+	sched.mutex.Unlock()
+	if sched.tryLock() {
+		sched.unlock()
+	}
+}
+
+// nextDeferred removes and returns the first deferred callback, or nil.
+func (sched *scheduler) nextDeferred() func() {
+	sched.mutex.Lock()
+	defer sched.mutex.Unlock()
+	if len(sched.deferred) == 0 {
+		return nil
+	}
+	fn := sched.deferred[0]
+	sched.deferred[0] = nil
+	sched.deferred = sched.deferred[1:]
+	return fn
+}
+
+// hasDeferred returns true if callbacks are deferred.
+func (sched *scheduler) hasDeferred() bool {
+	sched.mutex.Lock()
+	defer sched.mutex.Unlock()
+	return len(sched.deferred) > 0
+}
+
+// queueRender queues the view model to be rendered unless it is already queued.
+func (sched *scheduler) queueRender(vm *ViewModel) {
+	if vm.dirty {
+		return
+	}
+	vm.dirty = true
+	sched.queue = append(sched.queue, vm)
+	sched.schedule()
+}
+
+// queueHook queues the lifecycle hook to be called after the current render.
+func (sched *scheduler) queueHook(fn func()) {
+	sched.hooks = append(sched.hooks, fn)
+}
+
+// callHooks calls the queued lifecycle hooks in order, including hooks queued while calling.
+func (sched *scheduler) callHooks() {
+	for len(sched.hooks) > 0 {
+		hooks := sched.hooks
+		sched.hooks = nil
+		for _, fn := range hooks {
+			fn()
+		}
+	}
+}
+
+// nextTick queues the callback to be called on the update loop after the next flush.
+func (sched *scheduler) nextTick(fn func()) {
+	sched.ticks = append(sched.ticks, fn)
+	sched.schedule()
+}
+
+// nextTickUnlocked queues the callback to be called outside of the update loop after the next flush.
+func (sched *scheduler) nextTickUnlocked(fn func()) {
+	sched.afters = append(sched.afters, fn)
+	sched.schedule()
+}
+
+// schedule schedules a flush unless a flush is already scheduled.
+func (sched *scheduler) schedule() {
+	if sched.scheduled {
+		return
+	}
+	sched.scheduled = true
+	sched.doc.Schedule(sched.run)
+}
+
+// run flushes on the update loop, then calls the callbacks which are outside of the update loop.
+func (sched *scheduler) run() {
+	sched.lock()
+	sched.flush()
+	afters := sched.afters
+	sched.afters = nil
+	sched.unlock()
+
+	for _, fn := range afters {
+		fn()
+	}
+}
+
+// flush renders the queued view models, parents before children, then calls the lifecycle hooks
+// and the next tick callbacks.
+// A parent renders its children, so children which are rendered by their parent are not rendered again.
+func (sched *scheduler) flush() {
+	for len(sched.queue) > 0 || len(sched.ticks) > 0 {
+		queue := sched.queue
+		sched.queue = nil
+		sort.SliceStable(queue, func(i, j int) bool {
+			return queue[i].depth < queue[j].depth
+		})
+		for _, vm := range queue {
+			if vm.dirty {
+				vm.render()
+			}
+		}
+		sched.callHooks()
+		if len(sched.queue) > 0 {
+			continue
+		}
+
+		ticks := sched.ticks
+		sched.ticks = nil
+		for _, fn := range ticks {
+			fn()
+		}
+	}
+	sched.scheduled = false
+}
+
+// goid returns the id of the current goroutine, parsed from the header of its stack trace,
+// e.g. "goroutine 18 [running]:".
+func goid() int64 {
+	var buf [64]byte
+	b := bytes.TrimPrefix(buf[:runtime.Stack(buf[:], false)], []byte("goroutine "))
+	if i := bytes.IndexByte(b, ' '); i >= 0 {
+		b = b[:i]
+	}
+	id, _ := strconv.ParseInt(string(b), 10, 64)
+	return id
+}
@@ -0,0 +1,41 @@
+package vue
+
+import (
+	"bytes"
+
+	"golang.org/x/net/html"
+)
+
+// RenderToString renders the component to an html string with the given data.
+// Without data, the data option of the component is used.
+// Like subcomponents, the template of the component must have a single root element.
+// The rendered html includes subcomponents, which allows for fast first paint of server pages.
+// The first error while rendering is returned, the error handler of the component is not called.
+func RenderToString(comp *Comp, data interface{}) (string, error) {
+	// The component is rendered from its template root, similar to a subcomponent.
+	var err error
+	ssr := *comp
+	ssr.isSub = true
+	ssr.hydrate = false
+	ssr.handler = func(_ Context, rerr error) {
+		if err == nil {
+			err = rerr
+		}
+	}
+	if data != nil {
+		ssr.data = data
+	}
+
+	doc := NewMemDocument("")
+	vm := newViewModel(&ssr, newSubNode(doc, &ssr), nil, nil)
+	defer vm.release()
+	if err != nil {
+		return "", err
+	}
+
+	buf := bytes.NewBuffer(nil)
+	if err := html.Render(buf, vm.vnode.node.(*memNode).node); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
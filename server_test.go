@@ -0,0 +1,90 @@
+package vue
+
+import (
+	"testing"
+)
+
+func TestRenderToString(t *testing.T) {
+	comp := newTestComp(t,
+		Template(`<ol><todo-item v-for="Item in Todos" v-bind:Todo="Item"></todo-item></ol>`),
+		Data(&testData{}),
+		Sub("todo-item", newTestComp(t,
+			Props("Todo"),
+			Template(`<li v-bind:title="Todo.Text">{{ Todo.Text }}</li>`),
+		)),
+	)
+
+	data := &testData{Todos: []testTodo{{"a"}, {"b"}}}
+	got, err := RenderToString(comp, data)
+	if err != nil {
+		t.Fatalf("failed to render: %v", err)
+	}
+	if want := `<ol><li title="a">a</li><li title="b">b</li></ol>`; got != want {
+		t.Errorf("rendered %q, expected %q", got, want)
+	}
+
+	if _, err := RenderToString(newTestComp(t, Props("Todo"), Template(`<p v-if="Todo"></p>`)), nil); err == nil {
+		t.Errorf("expected error for unknown data field")
+	}
+}
+
+func TestHydrate(t *testing.T) {
+	tmpl := `<ol><li v-for="Todo in Todos" v-bind:title="Todo.Text">{{ Message }}</li></ol>`
+	doc := NewMemDocument(`<div id="app"><ol><li title="a">hello</li><!-- server --><li title="b">stale</li></ol></div>`)
+	first := doc.QuerySelector("li")
+
+	var mismatches []string
+	data := &testData{Message: "hello", Todos: []testTodo{{"a"}, {"b"}, {"c"}}}
+	_, err := New(Backend(doc), El("#app"), Template(tmpl), Data(data), Hydrate(func(msg string) {
+		mismatches = append(mismatches, msg)
+	}))
+	if err != nil {
+		t.Fatalf("failed to create view model: %v", err)
+	}
+
+	if got := doc.QuerySelector("li"); got != first {
+		t.Errorf("expected server rendered element to be adopted")
+	}
+	want := `<ol><li title="a">hello</li><!-- server --><li title="b">hello</li><li title="c">hello</li></ol>`
+	if got := doc.HTML("#app"); got != want {
+		t.Errorf("hydrated %q, expected %q", got, want)
+	}
+	if len(mismatches) != 2 {
+		t.Errorf("expected 2 mismatches, got %q", mismatches)
+	}
+}
+
+func TestHydrateSubcomponent(t *testing.T) {
+	var clicked []string
+	sub := newTestComp(t, Props("Todo"), Method("Click", func(vctx Context) {
+		clicked = append(clicked, vctx.Get("Todo.Text").(string))
+	}), Template(`<li v-bind:title="Todo.Text" v-on:click="Click">{{ Todo.Text }}</li>`))
+	tmpl := `<ol><todo-item v-for="Item in Todos" v-bind:Todo="Item"></todo-item></ol>`
+	doc := NewMemDocument(`<div id="app"><ol><li title="a">a</li><li title="b">stale</li></ol></div>`)
+	first := doc.QuerySelector("li")
+
+	var mismatches []string
+	data := &testData{Todos: []testTodo{{"a"}, {"b"}}}
+	_, err := New(Backend(doc), El("#app"), Template(tmpl), Data(data), Sub("todo-item", sub), Hydrate(func(msg string) {
+		mismatches = append(mismatches, msg)
+	}))
+	if err != nil {
+		t.Fatalf("failed to create view model: %v", err)
+	}
+
+	// The server rendered roots of subcomponents are adopted, and their listeners are added.
+	if got := doc.QuerySelector("li"); got != first {
+		t.Errorf("expected server rendered element of subcomponent to be adopted")
+	}
+	want := `<ol><li title="a">a</li><li title="b">b</li></ol>`
+	if got := doc.HTML("#app"); got != want {
+		t.Errorf("hydrated %q, expected %q", got, want)
+	}
+	if len(mismatches) != 1 {
+		t.Errorf("expected 1 mismatch, got %q", mismatches)
+	}
+	doc.Dispatch(first, "click", MemEventInit{})
+	if len(clicked) != 1 || clicked[0] != "a" {
+		t.Errorf("clicked %v, expected the adopted element to be listened to", clicked)
+	}
+}
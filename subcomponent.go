@@ -1,19 +1,33 @@
 package vue
 
+import (
+	"golang.org/x/net/html"
+)
+
 // subs maps elements to subcomponents
 type subs map[string]*sub
 
 // sub contains all the subcomponent instances for a component.
+// Instances are matched by key, otherwise by order, to the instances of the previous render.
 type sub struct {
 	comp      *Comp
 	index     int
-	instances map[int]*instance
+	props     map[string]interface{}
+	models    map[string]string
+	listeners map[string][]string
+	instances []*instance
+	prev      []*instance
 }
 
 // instance contains a view model with props.
+// The models map props to the data fields of the parent which are set by update events.
+// The listeners map events of the instance to the handlers of the parent.
 type instance struct {
-	props map[string]interface{}
-	vm    *ViewModel
+	key       string
+	props     map[string]interface{}
+	models    map[string]string
+	listeners map[string][]string
+	vm        *ViewModel
 }
 
 // newSubs creates a new map of subcomponents.
@@ -27,10 +41,7 @@ func newSubs(comps map[string]*Comp) subs {
 
 // newSub creates a new subcomponent.
 func newSub(comp *Comp) *sub {
-	return &sub{
-		comp:      comp,
-		instances: make(map[int]*instance, 0),
-	}
+	return &sub{comp: comp}
 }
 
 // putProp puts the props in the subcomponent.
@@ -44,54 +55,99 @@ func (subs subs) putProp(element, field string, data interface{}) bool {
 	return sub.putProp(field, data)
 }
 
-// putProp puts the props in the instance.
+// putProp puts the props for the next instance.
 // Returns false if the subcomponent is not expecting the prop.
 func (sub *sub) putProp(field string, data interface{}) bool {
 	if _, ok := sub.comp.props[field]; !ok {
 		return false
 	}
 
-	if inst, ok := sub.instances[sub.index]; ok {
-		if inst.props == nil {
-			inst.props = map[string]interface{}{field: data}
-		} else {
-			inst.props[field] = data
-		}
+	if sub.props == nil {
+		sub.props = map[string]interface{}{field: data}
 	} else {
-		sub.instances[sub.index] = &instance{props: map[string]interface{}{field: data}}
+		sub.props[field] = data
 	}
 	return true
 }
 
+// putModel puts the prop bound to the data field of the parent for the next instance.
+// Returns false if the subcomponent is not expecting the prop.
+func (sub *sub) putModel(prop, field string, data interface{}) bool {
+	if !sub.putProp(prop, data) {
+		return false
+	}
+
+	if sub.models == nil {
+		sub.models = map[string]string{prop: field}
+	} else {
+		sub.models[prop] = field
+	}
+	return true
+}
+
+// putListener puts the handler of the parent listening to the event for the next instance.
+func (sub *sub) putListener(event, handler string) {
+	if sub.listeners == nil {
+		sub.listeners = make(map[string][]string, 1)
+	}
+	sub.listeners[event] = append(sub.listeners[event], handler)
+}
+
 // newInstance creates a new instance of the subcomponent with props.
-// // Returns false if the element is not a subcomponent.
-func (subs subs) newInstance(element string, parent *ViewModel) bool {
-	sub, ok := subs[element]
+// Returns false if the element is not a subcomponent.
+func (subs subs) newInstance(node *html.Node, parent *ViewModel) bool {
+	sub, ok := subs[node.Data]
 	if !ok {
 		return false
 	}
-	return sub.newInstance(parent)
+	props, errs := sub.comp.resolveProps(node.Data, sub.props)
+	for _, err := range errs {
+		parent.handleError(err)
+	}
+	sub.props = props
+	sub.newInstance(attrKey(node.Attr), parent)
+	return true
 }
 
-// newInstance creates a new instance of the subcomponent with props.
-func (sub *sub) newInstance(parent *ViewModel) bool {
-	if inst, ok := sub.instances[sub.index]; ok {
-		if inst.vm == nil {
-			inst.vm = newViewModel(sub.comp, parent.bus, inst.props)
-		} else {
-			inst.vm.props = inst.props
-			inst.vm.render()
+// newInstance reuses an instance of the previous render with the key, or creates a new instance.
+// Without a key, unkeyed instances of the previous render are reused in order.
+func (sub *sub) newInstance(key string, parent *ViewModel) {
+	props, models, listeners := sub.props, sub.models, sub.listeners
+	sub.props, sub.models, sub.listeners = nil, nil, nil
+
+	if inst := sub.match(key); inst != nil {
+		inst.props = props
+		inst.models = models
+		inst.listeners = listeners
+		inst.vm.props = props
+		inst.vm.models = models
+		inst.vm.listeners = listeners
+		inst.vm.mutated = true
+		inst.vm.render()
+		sub.instances = append(sub.instances, inst)
+		return
+	}
+
+	vnode := newSubNode(parent.vnode.doc, sub.comp)
+	vm := newViewModel(sub.comp, vnode, parent, props)
+	vm.models = models
+	vm.listeners = listeners
+	sub.instances = append(sub.instances, &instance{key: key, props: props, models: models, listeners: listeners, vm: vm})
+}
+
+// match removes and returns the matching instance of the previous render, otherwise nil.
+func (sub *sub) match(key string) *instance {
+	for i, inst := range sub.prev {
+		if inst != nil && inst.key == key {
+			sub.prev[i] = nil
+			return inst
 		}
-	} else {
-		vm := newViewModel(sub.comp, parent.bus, nil)
-		sub.instances[sub.index] = &instance{vm: vm}
 	}
-	sub.index++
-	return true
+	return nil
 }
 
 // vnode retrieves a virtual node of the subcomponent.
-// // Returns false if the element is not a subcomponent.
+// Returns false if the element is not a subcomponent.
 func (subs subs) vnode(element string) (*vnode, bool) {
 	sub, ok := subs[element]
 	if !ok {
@@ -101,14 +157,62 @@ func (subs subs) vnode(element string) (*vnode, bool) {
 	return vnode, ok
 }
 
-// vnode retrieves a virtual node of the subcomponent.
+// instance retrieves the view model of the next instance of the subcomponent of the element.
+func (subs subs) instance(element string) (*ViewModel, bool) {
+	sub, ok := subs[element]
+	if !ok {
+		return nil, false
+	}
+	return sub.instance()
+}
+
+// elementVnode retrieves a virtual node of the subcomponent if the node is a subcomponent element.
+func (subs subs) elementVnode(node *html.Node) (*vnode, bool) {
+	if node.Type != html.ElementNode {
+		return nil, false
+	}
+	return subs.vnode(node.Data)
+}
+
+// vnode retrieves the virtual node of the next instance in order.
+// The deferred first render of an instance which is not hydrated is rendered instead.
 func (sub *sub) vnode() (*vnode, bool) {
-	inst, ok := sub.instances[sub.index]
+	vm, ok := sub.instance()
 	if !ok {
 		return nil, false
 	}
+	if vm.hydrating {
+		vm.hydrating = false
+		vm.render()
+	}
+	return vm.vnode, true
+}
+
+// instance retrieves the view model of the next instance in order.
+func (sub *sub) instance() (*ViewModel, bool) {
+	if sub.index >= len(sub.instances) {
+		return nil, false
+	}
+	inst := sub.instances[sub.index]
 	sub.index++
-	return inst.vm.vnode, true
+	return inst.vm, true
+}
+
+// release releases the instances of the previous render which were not reused.
+func (subs subs) release() {
+	for _, sub := range subs {
+		sub.release()
+	}
+}
+
+// release cleans up and unmounts unused subcomponent instances.
+func (sub *sub) release() {
+	for _, inst := range sub.prev {
+		if inst != nil {
+			inst.vm.release()
+		}
+	}
+	sub.prev = nil
 }
 
 // reset resets all subcomponents.
@@ -118,11 +222,18 @@ func (subs subs) reset() {
 	}
 }
 
-// reset cleans up and unmounts unused subcomponent instances.
+// reset prepares the instances of this render to be matched by the next render.
+// The previous instances which were not released are kept, e.g. if the render was aborted.
 func (sub *sub) reset() {
-	for i := sub.index; i < len(sub.instances); {
-		sub.instances[i].vm.release()
-		delete(sub.instances, i)
+	for _, inst := range sub.prev {
+		if inst != nil {
+			sub.instances = append(sub.instances, inst)
+		}
 	}
+	sub.prev = sub.instances
+	sub.instances = nil
 	sub.index = 0
+	sub.props = nil
+	sub.models = nil
+	sub.listeners = nil
 }
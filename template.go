@@ -2,145 +2,190 @@ package vue
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"reflect"
 	"strings"
 
-	"github.com/cbroglie/mustache"
 	"golang.org/x/net/html"
 	"golang.org/x/net/html/atom"
+
+	"github.com/tigerbot/vue/expr"
 )
 
 const (
-	v      = "v-"
-	vBind  = "v-bind"
-	vFor   = "v-for"
-	vHtml  = "v-html"
-	vIf    = "v-if"
-	vModel = "v-model"
-	vOn    = "v-on"
+	v              = "v-"
+	vBindShorthand = ":"
+	vBind          = "v-bind"
+	vElse          = "v-else"
+	vElseIf        = "v-else-if"
+	vFor           = "v-for"
+	vHtml          = "v-html"
+	vIf            = "v-if"
+	vModel         = "v-model"
+	vOn            = "v-on"
+	vShow          = "v-show"
 )
 
-var attrOrder = []string{vFor, vIf, vModel, vOn, vBind, vHtml}
+var attrOrder = []string{vFor, vIf, vElseIf, vElse, vOn, vBind, vShow, vModel, vHtml}
 
 // render executes and renders the prepared state.
+// Errors abort the render and are passed to the error handler, the previous render remains.
 func (vm *ViewModel) render() {
+	defer vm.catch()
+	defer vm.subs.reset()
+
+	vm.dirty = false
+	vm.binding = &scope{}
 	vm.updateComputed()
 	node := vm.execute()
+	vm.releaseReceived()
+	// The handlers are bound before rendering, instances may emit events to them when created.
+	vm.bound, vm.binding = vm.binding, nil
 
-	vm.subs.reset()
+	vm.subs.release()
 	if vm.comp.isSub {
 		var ok bool
 		if node, ok = firstElement(node); !ok {
-			must(fmt.Errorf("failed to find first element from node: %s", node.Data))
+			panic(&TemplateError{Err: errors.New("failed to find first element")})
 		}
+		vm.vnode.tmplAttrs = node.Attr
+		if vm.hydrating {
+			vm.vnode.hydrateAttributes(vm.vnode.rootAttributes(), vm.mismatch)
+		} else {
+			vm.vnode.renderRootAttributes()
+		}
+	}
+	if vm.hydrating {
+		vm.hydrating = false
+		vm.vnode.hydrate(node, vm.subs, vm.mismatch)
+	} else {
+		vm.vnode.render(node, vm.subs)
+	}
+	vm.targets = make(map[string][]*handler, len(vm.targets))
+	vm.vnode.index(vm.targets, true)
+
+	if vm.mounted {
+		vm.queueHook(hookUpdated)
 	}
-	vm.vnode.render(node, vm.subs)
-	vm.subs.reset()
 }
 
 // execute executes the template with the given data to be rendered.
 func (vm *ViewModel) execute() *html.Node {
-	node := parseNode(vm.comp.tmpl)
-
-	vm.executeElement(node)
-	vm.executeText(node)
-
+	node := cloneNode(vm.comp.prog.root)
+	vm.executeElement(node, nil)
 	return node
 }
 
-// executeElement recursively traverses the html node and templates the elements.
+// executeElement recursively traverses the html node and templates the elements and texts.
+// The scope binds the names of the loops around the node.
 // The next node is always returned which allows execution to jump around as needed.
-func (vm *ViewModel) executeElement(node *html.Node) *html.Node {
-	// Leave the text nodes to be executed.
-	if node.Type != html.ElementNode {
+func (vm *ViewModel) executeElement(node *html.Node, sc *scope) *html.Node {
+	switch node.Type {
+	case html.TextNode:
+		vm.executeText(node, sc)
+		return node.NextSibling
+	case html.ElementNode:
+	default:
 		return node.NextSibling
 	}
 
-	// Order attributes before execution.
-	orderAttrs(node)
-
 	// Execute attributes.
+	// The children of the vue html attribute are raw html which is not executed.
+	raw := false
 	for i := 0; i < len(node.Attr); i++ {
 		attr := node.Attr[i]
 		if strings.HasPrefix(attr.Key, v) {
 			node.Attr = append(node.Attr[:i], node.Attr[i+1:]...)
 			i--
+			raw = raw || attr.Key == vHtml
 
 			// The current node is not longer valid in favor of the next node.
-			if next, modified := vm.executeAttr(node, attr); modified {
+			if next, modified := vm.executeAttr(node, attr, sc); modified {
 				return next
 			}
 		}
 	}
 
 	// Execute subcomponent.
-	if vm.subs.newInstance(node.Data, vm) {
+	if vm.subs.newInstance(node, vm) || raw {
 		return node.NextSibling
 	}
 
 	// Execute children.
 	for child := node.FirstChild; child != nil; {
-		child = vm.executeElement(child)
+		child = vm.executeElement(child, sc)
+	}
+
+	// The options of a model are selected after they are executed.
+	if node.DataAtom == atom.Select {
+		vm.selectOptions(node)
 	}
 
 	return node.NextSibling
 }
 
-// executeText recursively executes the text node.
-func (vm *ViewModel) executeText(node *html.Node) {
-	switch node.Type {
-	case html.TextNode:
-		if strings.TrimSpace(node.Data) == "" {
-			return
-		}
+// executeText executes the text node by interpolating its expressions.
+func (vm *ViewModel) executeText(node *html.Node, sc *scope) {
+	if strings.TrimSpace(node.Data) == "" {
+		return
+	}
 
-		var err error
-		node.Data, err = mustache.Render(node.Data, vm.data, vm.props, vm.cache)
-		must(err)
-	case html.ElementNode:
-		for child := node.FirstChild; child != nil; child = child.NextSibling {
-			vm.executeText(child)
+	defer locate(node.Parent, "")
+	text := vm.comp.prog.compiledText(node.Data)
+	if text == nil {
+		return
+	}
+
+	sb := &strings.Builder{}
+	for i, x := range text.exprs {
+		sb.WriteString(text.literals[i])
+		if val := vm.evalExpr(x, sc); val != nil {
+			fmt.Fprint(sb, val)
 		}
 	}
+	sb.WriteString(text.literals[len(text.exprs)])
+	node.Data = sb.String()
 }
 
 // executeAttr executes the given vue attribute.
 // The next node will be executed next if the html was modified unless it is nil.
-func (vm *ViewModel) executeAttr(node *html.Node, attr html.Attribute) (*html.Node, bool) {
-	vals := strings.Split(attr.Key, ":")
-	typ, part := vals[0], ""
-	if len(vals) > 1 {
-		part = vals[1]
-	}
+func (vm *ViewModel) executeAttr(node *html.Node, attr html.Attribute, sc *scope) (*html.Node, bool) {
+	defer locate(node, attr.Key)
+
+	typ, part := splitAttr(attr.Key)
 
 	var next *html.Node
 	var modified bool
 	switch typ {
 	case vBind:
-		vm.executeAttrBind(node, part, attr.Val)
+		vm.executeAttrBind(node, part, attr.Val, sc)
 	case vFor:
-		next, modified = vm.executeAttrFor(node, attr.Val)
+		next, modified = vm.executeAttrFor(node, attr.Val, sc)
 	case vHtml:
-		vm.executeAttrHtml(node, attr.Val)
-	case vIf:
-		next, modified = vm.executeAttrIf(node, attr.Val)
+		vm.executeAttrHtml(node, attr.Val, sc)
+	case vIf, vElseIf:
+		next, modified = vm.executeAttrIf(node, attr.Val, sc)
+	case vElse:
+		// The previous branches of the chain are false, otherwise the node was removed.
 	case vModel:
-		vm.executeAttrModel(node, attr.Val)
+		vm.executeAttrModel(node, part, attr.Val, sc)
 	case vOn:
-		vm.executeAttrOn(node, part, attr.Val)
+		vm.executeAttrOn(node, part, attr.Val, sc)
+	case vShow:
+		vm.executeAttrShow(node, attr.Val, sc)
 	default:
-		must(fmt.Errorf("unknown vue attribute: %v", typ))
+		panic(&TemplateError{Err: fmt.Errorf("unknown vue attribute: %s", typ)})
 	}
 	return next, modified
 }
 
 // executeAttrBind executes the vue bind attribute.
-func (vm *ViewModel) executeAttrBind(node *html.Node, key, field string) {
-	value := vm.Get(field)
+func (vm *ViewModel) executeAttrBind(node *html.Node, key, src string, sc *scope) {
+	value := vm.eval(src, sc)
 	if value == nil {
-		must(fmt.Errorf("unknown data field: %s", field))
+		panic(&FieldError{Field: src, Err: errNilField})
 	}
 
 	prop := strings.Title(key)
@@ -149,14 +194,12 @@ func (vm *ViewModel) executeAttrBind(node *html.Node, key, field string) {
 	}
 
 	if key == "class" {
-		class := formatAttrClass(value)
-		node.Attr = append(node.Attr, html.Attribute{Key: key, Val: class})
+		mergeAttr(node, key, formatAttrClass(value))
 		return
 	}
 
 	if key == "style" {
-		style := formatAttrStyle(value)
-		node.Attr = append(node.Attr, html.Attribute{Key: key, Val: style})
+		mergeAttr(node, key, formatAttrStyle(value))
 		return
 	}
 
@@ -170,65 +213,52 @@ func (vm *ViewModel) executeAttrBind(node *html.Node, key, field string) {
 }
 
 // executeAttrFor executes the vue for attribute.
-func (vm *ViewModel) executeAttrFor(node *html.Node, value string) (*html.Node, bool) {
-	vals := strings.Split(value, "in")
-	name := bytes.TrimSpace([]byte(vals[0]))
-	field := strings.TrimSpace(vals[1])
-
-	slice := vm.getValue(field)
-	if k := slice.Kind(); k != reflect.Slice && k != reflect.Array {
-		must(fmt.Errorf("slice not found for field: %s", field))
-	}
-
-	n := slice.Len()
-	if n == 0 {
-		next := node.NextSibling
-		node.Parent.RemoveChild(node)
-		return next, true
-	}
-
-	elem := bytes.NewBuffer(nil)
-	must(html.Render(elem, node))
-
-	buf := bytes.NewBuffer(nil)
-	for i := 0; i < n; i++ {
-		key := fmt.Sprintf("%s[%d]", field, i)
-
-		b := bytes.Replace(elem.Bytes(), name, []byte(key), -1)
-		_, err := buf.Write(b)
-		must(err)
+// Every item is executed as a clone of the node, with the names of the loop bound in a nested scope.
+func (vm *ViewModel) executeAttrFor(node *html.Node, value string, sc *scope) (*html.Node, bool) {
+	loop, err := vm.comp.prog.loop(value)
+	if err != nil {
+		panic(&TemplateError{Err: err})
 	}
 
-	nodes := parseNodes(buf)
-	for _, child := range nodes {
-		node.Parent.InsertBefore(child, node)
+	next := node.NextSibling
+	for i, item := range vm.loopItems(loop.src, sc) {
+		clone := cloneNode(node)
+		node.Parent.InsertBefore(clone, node)
+		vm.executeElement(clone, loop.scope(sc, item, i))
 	}
 	node.Parent.RemoveChild(node)
-	// The first child is the next node to execute.
-	return nodes[0], true
+	return next, true
 }
 
 // executeAttrHtml executes the vue html attribute.
-func (vm *ViewModel) executeAttrHtml(node *html.Node, field string) {
-	html, ok := vm.Get(field).(string)
+// The value is parsed into raw html which replaces the children, the raw html is not executed.
+func (vm *ViewModel) executeAttrHtml(node *html.Node, src string, sc *scope) {
+	html, ok := vm.eval(src, sc).(string)
 	if !ok {
-		must(fmt.Errorf("data field is not of type string: %T", field))
+		panic(&FieldError{Field: src, Err: errNotString})
 	}
 
-	nodes := parseNodes(strings.NewReader(html))
+	nodes, err := parseNodes(strings.NewReader(html))
+	if err != nil {
+		panic(&FieldError{Field: src, Err: err})
+	}
+	// The raw html replaces the children of the template.
+	for node.FirstChild != nil {
+		node.RemoveChild(node.FirstChild)
+	}
 	for _, child := range nodes {
 		node.AppendChild(child)
 	}
 }
 
-// executeAttrIf executes the vue if attribute.
-func (vm *ViewModel) executeAttrIf(node *html.Node, field string) (*html.Node, bool) {
-	negate := strings.HasPrefix(field, "!")
-	if negate {
-		field = field[1:]
-	}
-
-	if val, ok := vm.Get(field).(bool); ok && val != negate {
+// executeAttrIf executes the vue if and else if attributes.
+// The node is removed unless the expression is true, see expr.Truth.
+// Otherwise the else if and else branches which follow the node are removed.
+func (vm *ViewModel) executeAttrIf(node *html.Node, src string, sc *scope) (*html.Node, bool) {
+	if expr.Truth(vm.eval(src, sc)) {
+		for branch, ok := nextBranch(node); ok; branch, ok = nextBranch(node) {
+			node.Parent.RemoveChild(branch)
+		}
 		return nil, false
 	}
 
@@ -237,49 +267,170 @@ func (vm *ViewModel) executeAttrIf(node *html.Node, field string) (*html.Node, b
 	return next, true
 }
 
+// executeAttrShow executes the vue show attribute.
+// The node is hidden by its style unless the expression is true, see expr.Truth.
+// Unlike the vue if attribute, the node is kept, e.g. subcomponents and inputs keep their state.
+func (vm *ViewModel) executeAttrShow(node *html.Node, src string, sc *scope) {
+	if !expr.Truth(vm.eval(src, sc)) {
+		mergeAttr(node, "style", "display: none")
+	}
+}
+
+// nextBranch returns the next sibling element of the node if it has a vue else if or else attribute.
+// Text of only whitespace and comments may be in between the branches.
+func nextBranch(node *html.Node) (*html.Node, bool) {
+	for next := node.NextSibling; next != nil; next = next.NextSibling {
+		switch {
+		case next.Type == html.ElementNode:
+			return next, hasAttr(next, vElseIf) || hasAttr(next, vElse)
+		case next.Type == html.CommentNode:
+		case next.Type == html.TextNode && strings.TrimSpace(next.Data) == "":
+		default:
+			return nil, false
+		}
+	}
+	return nil, false
+}
+
+// prevBranch returns true if the previous sibling element of the node has a vue if or else if attribute.
+// Text of only whitespace and comments may be in between the branches.
+func prevBranch(node *html.Node) bool {
+	for prev := node.PrevSibling; prev != nil; prev = prev.PrevSibling {
+		switch {
+		case prev.Type == html.ElementNode:
+			return hasAttr(prev, vIf) || hasAttr(prev, vElseIf)
+		case prev.Type == html.CommentNode:
+		case prev.Type == html.TextNode && strings.TrimSpace(prev.Data) == "":
+		default:
+			return false
+		}
+	}
+	return false
+}
+
+// hasAttr returns true if the node has the attribute.
+func hasAttr(node *html.Node, key string) bool {
+	return attrIndex(node, key) >= 0
+}
+
 // executeAttrModel executes the vue model attribute.
-func (vm *ViewModel) executeAttrModel(node *html.Node, field string) {
-	typ := "input"
-	node.Attr = append(node.Attr, html.Attribute{Key: typ, Val: field})
+// The field path of the model is rendered with the modifiers for the event listener to set the field,
+// e.g. input="Message" or change.number="Count", then the value of the field is rendered by the element.
+// The model is executed after bound attributes, e.g. the values of checkboxes and radio buttons.
+// Subcomponents receive the value as a prop instead, then emit its update event to set the field.
+func (vm *ViewModel) executeAttrModel(node *html.Node, arg, src string, sc *scope) {
+	field := vm.path(src, sc)
+	value := vm.getValue(field)
+	if !value.IsValid() {
+		panic(&FieldError{Field: field, Err: errUnknownField})
+	}
 
-	val, ok := vm.Get(field).(string)
-	if !ok {
-		must(fmt.Errorf("data field is not of type string: %T", field))
+	prop, modifiers := splitModel(arg)
+	if sub, ok := vm.subs[node.Data]; ok {
+		if !sub.putModel(prop, field, value.Interface()) {
+			panic(&TemplateError{Err: fmt.Errorf("undeclared prop %s of subcomponent %s", prop, node.Data)})
+		}
+		return
 	}
-	node.Attr = append(node.Attr, html.Attribute{Key: "value", Val: val})
 
-	vm.addEventListener(typ, vm.vModel)
+	kind := nodeModelKind(node)
+	if !isNumberModel(reflectType{value.Type()}, kind, modifiers) {
+		panic(&FieldError{Field: field, Err: errNumberType})
+	}
+	typ := "input"
+	if _, ok := modSet(strings.TrimPrefix(modifiers, "."))["Lazy"]; ok || kind != modelText {
+		typ = "change"
+	}
+	vm.listen(node, html.Attribute{Namespace: vModel, Key: typ + modifiers, Val: field})
+
+	if err := renderModel(node, kind, value); err != nil {
+		panic(&FieldError{Field: field, Err: err})
+	}
+	if kind == modelSelect || kind == modelSelectMultiple {
+		node.Attr = append(node.Attr, html.Attribute{Key: modelAttr, Val: field})
+	}
 }
 
 // executeAttrOn executes the vue on attribute.
-func (vm *ViewModel) executeAttrOn(node *html.Node, typ, method string) {
+// The handler is rendered with the names of loops replaced by their paths or by the names of their bound values,
+// to be evaluated by the event listener. The handler is kept by the virtual node of the element, see vnode.handlers.
+func (vm *ViewModel) executeAttrOn(node *html.Node, typ, src string, sc *scope) {
+	// Handlers may only have modifiers, e.g. submit.prevent.
+	if src == "" {
+		vm.listen(node, html.Attribute{Namespace: vOn, Key: typ})
+		return
+	}
+
+	x := vm.parse(src)
+	handler := vm.comp.prog.handler(expr.Substitute(x, func(field *expr.Field) expr.Expr {
+		return vm.substitute(field, sc)
+	}))
 	event := strings.Split(typ, ".")[0]
-	node.Attr = append(node.Attr, html.Attribute{Key: typ, Val: method})
+	if sub, ok := vm.subs[node.Data]; ok && sub.comp.emitsEvent(event) {
+		sub.putListener(strings.ToLower(event), handler)
+		// Declared events are only listened to on the instance, others fall through to its root element.
+		if sub.comp.emits != nil {
+			return
+		}
+	}
 
-	vm.addEventListener(event, vm.vOn)
-	vm.bus.sub(event, method)
+	vm.listen(node, html.Attribute{Namespace: vOn, Key: typ, Val: handler})
+	if method := handlerMethod(x); method != "" {
+		vm.bus.sub(event, method)
+	}
 }
 
 // parseNode parses the template into an html node.
 // The node returned is a placeholder, not to be rendered.
-func parseNode(tmpl string) *html.Node {
-	nodes := parseNodes(strings.NewReader(tmpl))
+func parseNode(tmpl string) (*html.Node, error) {
+	nodes, err := parseNodes(strings.NewReader(tmpl))
+	if err != nil {
+		return nil, err
+	}
 	node := &html.Node{Type: html.ElementNode}
 	for _, child := range nodes {
 		node.AppendChild(child)
 	}
-	return node
+	return node, nil
 }
 
 // parseNodes parses the reader into html nodes.
-func parseNodes(reader io.Reader) []*html.Node {
+// Only elements and texts are rendered, so comments and doctypes are pruned.
+func parseNodes(reader io.Reader) ([]*html.Node, error) {
 	nodes, err := html.ParseFragment(reader, &html.Node{
 		Type:     html.ElementNode,
 		Data:     "div",
 		DataAtom: atom.Div,
 	})
-	must(err)
-	return nodes
+	if err != nil {
+		return nil, err
+	}
+	pruned := nodes[:0]
+	for _, node := range nodes {
+		if isRendered(node) {
+			pruneNodes(node)
+			pruned = append(pruned, node)
+		}
+	}
+	return pruned, nil
+}
+
+// pruneNodes recursively removes the children of the node which are not rendered.
+func pruneNodes(node *html.Node) {
+	for child := node.FirstChild; child != nil; {
+		next := child.NextSibling
+		if isRendered(child) {
+			pruneNodes(child)
+		} else {
+			node.RemoveChild(child)
+		}
+		child = next
+	}
+}
+
+// isRendered returns true for elements and texts.
+func isRendered(node *html.Node) bool {
+	return node.Type == html.ElementNode || node.Type == html.TextNode
 }
 
 // firstElement finds the first child element of a node.
@@ -293,6 +444,29 @@ func firstElement(node *html.Node) (*html.Node, bool) {
 	return nil, false
 }
 
+// expandShorthands expands the shorthand attributes of the node.
+// For example: :key -> v-bind:key
+func expandShorthands(node *html.Node) {
+	for i, attr := range node.Attr {
+		if strings.HasPrefix(attr.Key, vBindShorthand) {
+			node.Attr[i].Key = vBind + attr.Key
+		}
+	}
+}
+
+// splitAttr splits the key of the vue attribute into the type and the argument with modifiers.
+// For example: v-on:keyup.enter -> v-on, keyup.enter or v-model.lazy -> v-model, .lazy
+func splitAttr(key string) (string, string) {
+	typ, arg := key, ""
+	if i := strings.Index(key, ":"); i >= 0 {
+		typ, arg = key[:i], key[i+1:]
+	}
+	if i := strings.Index(typ, "."); i >= 0 {
+		typ, arg = typ[:i], typ[i:]+arg
+	}
+	return typ, arg
+}
+
 // orderAttrs orders the attributes of the node which orders the template execution.
 func orderAttrs(node *html.Node) {
 	n := len(node.Attr)
@@ -300,9 +474,9 @@ func orderAttrs(node *html.Node) {
 		return
 	}
 	attrs := make([]html.Attribute, 0, n)
-	for _, prefix := range attrOrder {
+	for _, typ := range attrOrder {
 		for _, attr := range node.Attr {
-			if strings.HasPrefix(attr.Key, prefix) {
+			if t, _ := splitAttr(attr.Key); t == typ {
 				attrs = append(attrs, attr)
 			}
 		}
@@ -316,6 +490,29 @@ func orderAttrs(node *html.Node) {
 	node.Attr = attrs
 }
 
+// mergeAttr merges the value into the class or style attribute of the node.
+// For example: color: red + display: none -> color: red; display: none
+func mergeAttr(node *html.Node, key, val string) {
+	sep := " "
+	if key == "style" {
+		sep = "; "
+	}
+	for i, attr := range node.Attr {
+		if attr.Key != key {
+			continue
+		}
+		prev := strings.TrimRight(attr.Val, sep)
+		switch {
+		case prev == "":
+			node.Attr[i].Val = val
+		case val != "":
+			node.Attr[i].Val = prev + sep + val
+		}
+		return
+	}
+	node.Attr = append(node.Attr, html.Attribute{Key: key, Val: val})
+}
+
 // formatAttrClass formats the value into a class attribute.
 // For example: { Active: true, DangerText: true } -> "active danger-text"
 // For type: struct { Active: bool `css:"active"`, DangerText: bool `css:"danger-text"` }
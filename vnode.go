@@ -2,94 +2,110 @@ package vue
 
 import (
 	"fmt"
-	"syscall/js"
 
 	"golang.org/x/net/html"
-	dom "honnef.co/go/js/dom/v2"
 )
 
-var document = dom.WrapDocument(js.Global().Get("document"))
-
 type vnode struct {
 	parent, firstChild, lastChild, prevSibling, nextSibling *vnode
 
 	attrs map[string]string
 	typ   html.NodeType
 	data  string
+	key   string
+
+	// The event handlers of the element, the id identifies the element of the handlers in the dom.
+	// The root of a subcomponent also has the handlers of its parent.
+	handlers, parentHandlers []*handler
+	id                       string
+
+	// The root attributes of a subcomponent from its template and its parent.
+	tmplAttrs, parentAttrs []html.Attribute
+	sub                    bool
 
-	node dom.Node
+	doc  Document
+	node Node
 }
 
 // newNode creates a virtual node by query selecting the given element.
-func newNode(el string) *vnode {
-	node := document.QuerySelector(el)
-	return &vnode{attrs: node.Attributes(), node: node}
+func newNode(doc Document, el string) (*vnode, error) {
+	node := doc.QuerySelector(el)
+	if node == nil {
+		return nil, fmt.Errorf("vue: failed to find element: %s", el)
+	}
+	return &vnode{attrs: node.Attributes(), doc: doc, node: node}, nil
 }
 
-// newSubNode creates a virtual subcomponent node from the given template.
-func newSubNode(tmpl string) *vnode {
-	node := parseNode(tmpl)
-	var ok bool
-	if node, ok = firstElement(node); !ok {
-		must(fmt.Errorf("failed to find first element from template: %s", tmpl))
-	}
-	return createElement(node)
+// newSubNode creates a virtual subcomponent node from the template of the component.
+// The template is validated to have a first element when the component is created.
+func newSubNode(doc Document, comp *Comp) *vnode {
+	node, _ := firstElement(comp.prog.root)
+	vnode := createElement(doc, node)
+	vnode.sub = true
+	return vnode
 }
 
 // createElement creates a virtual node element without children nor attributes.
-func createElement(node *html.Node) *vnode {
+func createElement(doc Document, node *html.Node) *vnode {
 	return &vnode{
 		typ:   node.Type,
 		data:  node.Data,
 		attrs: make(map[string]string, len(node.Attr)),
-		node:  document.CreateElement(node.Data),
+		doc:   doc,
+		node:  doc.CreateElement(node.Data),
 	}
 }
 
 // createNode recursively creates a virtual node from the html node.
-func createNode(node *html.Node, subs subs) *vnode {
-	vnode := &vnode{typ: node.Type, data: node.Data}
+func createNode(doc Document, node *html.Node, subs subs) *vnode {
+	vnode := &vnode{typ: node.Type, data: node.Data, doc: doc}
 	switch node.Type {
 	case html.ElementNode:
 		if subNode, ok := subs.vnode(node.Data); ok {
-			subNode.renderAttributes(node.Attr)
+			subNode.parentAttrs = node.Attr
+			subNode.renderRootAttributes()
 			return subNode
 		} else {
-			vnode.node = document.CreateElement(node.Data)
+			vnode.node = doc.CreateElement(node.Data)
 			vnode.attrs = make(map[string]string, len(node.Attr))
-			for _, attr := range node.Attr {
-				vnode.setAttr(attr.Key, attr.Val)
-			}
+			vnode.renderAttributes(node.Attr)
 			for child := node.FirstChild; child != nil; child = child.NextSibling {
-				vnode.append(createNode(child, subs))
+				vnode.append(createNode(doc, child, subs))
 			}
 		}
 	case html.TextNode:
-		vnode.node = document.CreateTextNode(node.Data)
+		vnode.node = doc.CreateTextNode(node.Data)
 	default:
-		must(fmt.Errorf("unknown node type: %v", node.Type))
+		panic(&TemplateError{Err: fmt.Errorf("unknown node type: %v", node.Type)})
 	}
 	return vnode
 }
 
 // render recursively renders the virtual node.
+// Children are rendered by position unless they have keys.
 func (dst *vnode) render(src *html.Node, subs subs) {
+	if hasKeys(src) {
+		dst.renderKeyed(src, subs)
+		return
+	}
+
 	for dstChild, srcChild := dst.firstChild, src.FirstChild; dstChild != nil || srcChild != nil; {
 		switch {
 		case dstChild == nil:
-			dst.append(createNode(srcChild, subs))
+			dst.append(createNode(dst.doc, srcChild, subs))
 		case srcChild == nil:
 			dst.remove(dstChild)
 		case dstChild.typ != srcChild.Type:
-			dst.replace(createNode(srcChild, subs), dstChild)
+			dst.replace(createNode(dst.doc, srcChild, subs), dstChild)
 		default:
 			switch srcChild.Type {
 			case html.ElementNode:
 				if subNode, ok := subs.vnode(srcChild.Data); ok {
-					subNode.renderAttributes(srcChild.Attr)
+					subNode.parentAttrs = srcChild.Attr
+					subNode.renderRootAttributes()
 					dst.replace(subNode, dstChild)
 				} else if dstChild.data != srcChild.Data {
-					dst.replace(createNode(srcChild, subs), dstChild)
+					dst.replace(createNode(dst.doc, srcChild, subs), dstChild)
 				} else {
 					dstChild.renderAttributes(srcChild.Attr)
 					dstChild.render(srcChild, subs)
@@ -99,7 +115,7 @@ func (dst *vnode) render(src *html.Node, subs subs) {
 					dstChild.setText(srcChild.Data)
 				}
 			default:
-				must(fmt.Errorf("unknown html node type: %v", srcChild.Type))
+				panic(&TemplateError{Err: fmt.Errorf("unknown html node type: %v", srcChild.Type)})
 			}
 		}
 		if dstChild != nil {
@@ -111,37 +127,105 @@ func (dst *vnode) render(src *html.Node, subs subs) {
 	}
 }
 
-// renderAttributes renders the attributes.
+// renderAttributes renders the attributes in order, the key and the handlers are kept by the virtual node instead.
 func (vnode *vnode) renderAttributes(attrs []html.Attribute) {
-	keys := make(map[string]struct{}, len(vnode.attrs)+len(attrs))
-	srcAttrs := make(map[string]string, len(attrs))
+	srcAttrs := make(map[string]struct{}, len(attrs))
+	vnode.key = ""
+	vnode.handlers = nil
 	for _, attr := range attrs {
-		keys[attr.Key] = struct{}{}
-		srcAttrs[attr.Key] = attr.Val
+		if attr.Key == keyAttr {
+			vnode.key = attr.Val
+			continue
+		}
+		if isHandler(attr) {
+			vnode.handlers = append(vnode.handlers, newHandler(attr))
+			continue
+		}
+		srcAttrs[attr.Key] = struct{}{}
+		if dstVal, ok := vnode.attrs[attr.Key]; !ok || dstVal != attr.Val {
+			vnode.setAttr(attr.Key, attr.Val)
+		}
 	}
 	for key := range vnode.attrs {
-		keys[key] = struct{}{}
+		if _, ok := srcAttrs[key]; !ok {
+			vnode.remAttr(key)
+		}
 	}
+	if vnode.id == "" && vnode.node != nil && (vnode.handlers != nil || vnode.parentHandlers != nil) {
+		vnode.id = newTargetID()
+		vnode.node.SetProperty(targetProp, vnode.id)
+	}
+}
 
-	for key := range keys {
-		if srcVal, ok := srcAttrs[key]; ok {
-			if dstVal, ok := vnode.attrs[key]; !ok || dstVal != srcVal {
-				vnode.setAttr(key, srcVal)
+// renderRootAttributes renders the root attributes of a subcomponent.
+// The attributes of the parent take precedence over the template, except classes and styles are merged.
+// The handlers of the parent are kept apart, they are called by the parent.
+func (vnode *vnode) renderRootAttributes() {
+	vnode.renderAttributes(vnode.rootAttributes())
+}
+
+// rootAttributes merges the root attributes of the subcomponent from its template and its parent.
+// The handlers of the parent are the parent handlers of the root.
+func (vnode *vnode) rootAttributes() []html.Attribute {
+	attrs := make([]html.Attribute, 0, len(vnode.tmplAttrs)+len(vnode.parentAttrs))
+	index := make(map[string]int, len(vnode.tmplAttrs)+len(vnode.parentAttrs))
+	vnode.parentHandlers = nil
+	for j, list := range [][]html.Attribute{vnode.tmplAttrs, vnode.parentAttrs} {
+		for _, attr := range list {
+			if isHandler(attr) {
+				if j == 0 {
+					attrs = append(attrs, attr)
+				} else {
+					vnode.parentHandlers = append(vnode.parentHandlers, newHandler(attr))
+				}
+				continue
+			}
+			i, ok := index[attr.Key]
+			switch {
+			case !ok:
+				index[attr.Key] = len(attrs)
+				attrs = append(attrs, attr)
+			case attr.Key == "class":
+				attrs[i].Val += " " + attr.Val
+			case attr.Key == "style":
+				attrs[i].Val += "; " + attr.Val
+			default:
+				attrs[i].Val = attr.Val
 			}
-		} else {
-			vnode.remAttr(key)
 		}
 	}
+	return attrs
+}
+
+// index indexes the handlers of the elements of the view model by their ids.
+// The roots of subcomponents are indexed with the handlers of the parent.
+func (vnode *vnode) index(targets map[string][]*handler, root bool) {
+	handlers := vnode.handlers
+	if vnode.sub && !root {
+		handlers = vnode.parentHandlers
+	}
+	if handlers != nil {
+		targets[vnode.id] = handlers
+	}
+	if vnode.sub && !root {
+		return
+	}
+	for child := vnode.firstChild; child != nil; child = child.nextSibling {
+		child.index(targets, false)
+	}
 }
 
 // setAttr sets an attribute of the element.
 func (vnode *vnode) setAttr(key, val string) {
 	vnode.attrs[key] = val
 	if vnode.node != nil {
-		if key == "value" {
-			vnode.node.Underlying().Set(key, val)
+		switch key {
+		case "value":
+			vnode.node.SetProperty(key, val)
+		case "checked", "selected":
+			vnode.node.SetProperty(key, true)
 		}
-		vnode.node.(dom.Element).SetAttribute(key, val)
+		vnode.node.SetAttribute(key, val)
 	}
 }
 
@@ -149,7 +233,10 @@ func (vnode *vnode) setAttr(key, val string) {
 func (vnode *vnode) remAttr(key string) {
 	delete(vnode.attrs, key)
 	if vnode.node != nil {
-		vnode.node.(dom.Element).RemoveAttribute(key)
+		if key == "checked" || key == "selected" {
+			vnode.node.SetProperty(key, false)
+		}
+		vnode.node.RemoveAttribute(key)
 	}
 }
 
@@ -163,6 +250,14 @@ func (vnode *vnode) setText(content string) {
 
 // append appends the child to the node.
 func (vnode *vnode) append(child *vnode) {
+	vnode.adopt(child)
+	if vnode.node != nil {
+		vnode.node.AppendChild(child.node)
+	}
+}
+
+// adopt links the child as the last child of the node without modifying the dom.
+func (vnode *vnode) adopt(child *vnode) {
 	prev := vnode.lastChild
 	if prev == nil {
 		vnode.firstChild = child
@@ -172,14 +267,13 @@ func (vnode *vnode) append(child *vnode) {
 	vnode.lastChild = child
 	child.parent = vnode
 	child.prevSibling = prev
-
-	if vnode.node != nil {
-		vnode.node.AppendChild(child.node)
-	}
 }
 
 // replace replaces a child with a new child.
 func (vnode *vnode) replace(newChild, oldChild *vnode) {
+	if newChild == oldChild {
+		return
+	}
 	prev, next := oldChild.prevSibling, oldChild.nextSibling
 	if prev == nil {
 		vnode.firstChild = newChild
@@ -3,46 +3,109 @@ package vue
 
 import (
 	"reflect"
-	"syscall/js"
+	"strconv"
 )
 
 // ViewModel is a vue view model, e.g. VM.
+// Its methods synchronize with the update loop, they may also be called on the loop, e.g. by methods of the component.
 type ViewModel struct {
-	comp  *Comp
-	vnode *vnode
-	data  reflect.Value
-	funcs map[string]js.Func
-	props map[string]interface{}
-	cache map[string]interface{}
-	subs  subs
-	bus   *bus
+	comp      *Comp
+	parent    *ViewModel
+	vnode     *vnode
+	data      reflect.Value
+	funcs     map[string]func()
+	targets   map[string][]*handler
+	props     map[string]interface{}
+	models    map[string]string
+	listeners map[string][]string
+	cache     map[string]interface{}
+	subs      subs
+	bus       *bus
+
+	// The values of the loop items bound to the handlers of the last render, and of the current render.
+	bound, binding *scope
+
+	// The values received from the channels of loops, and the channels iterated by the current render.
+	received map[interface{}][]reflect.Value
+	iterated map[interface{}]struct{}
+
+	computeds map[string]*computed
+	tracking  *computed
+	mutated   bool
+
+	ctx   Context
+	sched *scheduler
+	depth int
+	dirty bool
+
+	mounted  bool
+	released bool
+
+	hydrating bool
 }
 
 // New creates a new view model from the given options.
-func New(options ...Option) *ViewModel {
-	comp := Component(options...)
-	return newViewModel(comp, nil, nil)
+// Invalid options and a missing element return an error,
+// errors while rendering are passed to the error handler.
+func New(options ...Option) (*ViewModel, error) {
+	comp, err := Component(options...)
+	if err != nil {
+		return nil, err
+	}
+	doc := comp.doc
+	if doc == nil {
+		doc = defaultDocument()
+	}
+	vnode, err := newNode(doc, comp.el)
+	if err != nil {
+		return nil, err
+	}
+	vm := newViewModel(comp, vnode, nil, nil)
+
+	vm.sched.lock()
+	defer vm.sched.unlock()
+	vm.sched.callHooks()
+	return vm, nil
 }
 
 // newViewModel creates a new view model from the given component with props.
-func newViewModel(comp *Comp, bus *bus, props map[string]interface{}) *ViewModel {
-	var vnode *vnode
-	if comp.isSub {
-		vnode = newSubNode(comp.tmpl)
+// The component is rendered into the given virtual node.
+// The view model is mounted once the queued hooks are called, e.g. by New or by the scheduler.
+// Without a parent, the view model is the root of a new app.
+func newViewModel(comp *Comp, vnode *vnode, parent *ViewModel, props map[string]interface{}) *ViewModel {
+	vm := &ViewModel{
+		comp:     comp,
+		parent:   parent,
+		props:    props,
+		vnode:    vnode,
+		data:     comp.newData(),
+		subs:     newSubs(comp.subs),
+		cache:    make(map[string]interface{}, len(comp.computed)),
+		received: make(map[interface{}][]reflect.Value, 0),
+		iterated: make(map[interface{}]struct{}, 0),
+		funcs:    make(map[string]func(), 0),
+
+		computeds: newComputeds(comp.computed),
+		hydrating: parent == nil && comp.hydrate || parent != nil && parent.hydrating,
+	}
+	vm.ctx = &loopContext{vm: vm}
+	if parent == nil {
+		vm.bus = newBus(nil, vm)
+		vm.sched = newScheduler(vnode.doc)
+		vm.sched.lock()
+		defer vm.sched.unlock()
 	} else {
-		vnode = newNode(comp.el)
+		vm.bus = newBus(parent.bus, vm)
+		vm.sched = parent.sched
+		vm.depth = parent.depth + 1
 	}
-
-	vm := &ViewModel{
-		comp:  comp,
-		props: props,
-		vnode: vnode,
-		data:  comp.newData(),
-		subs:  newSubs(comp.subs),
-		funcs: make(map[string]js.Func, 0),
+	vnode.node.SetProperty(depthProp, strconv.Itoa(vm.depth))
+	vm.callHook(hookCreated)
+	// The first render of a subcomponent which is hydrated is deferred until its parent adopts its root element.
+	if parent == nil || !vm.hydrating {
+		vm.render()
 	}
-	vm.bus = newBus(bus, vm)
-	vm.render()
+	vm.queueMount()
 	return vm
 }
 
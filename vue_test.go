@@ -0,0 +1,1196 @@
+package vue
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testData struct {
+	Message string
+	Seen    bool
+	Todos   []testTodo
+}
+
+type testTodo struct {
+	Text string
+}
+
+func newTestVM(t *testing.T, tmpl string, options ...Option) (*ViewModel, *MemDocument) {
+	t.Helper()
+	doc := NewMemDocument(`<div id="app"></div>`)
+	options = append([]Option{Backend(doc), El("#app"), Template(tmpl)}, options...)
+	vm, err := New(options...)
+	if err != nil {
+		t.Fatalf("failed to create view model: %v", err)
+	}
+	return vm, doc
+}
+
+func newTestComp(t *testing.T, options ...Option) *Comp {
+	t.Helper()
+	comp, err := Component(options...)
+	if err != nil {
+		t.Fatalf("failed to create component: %v", err)
+	}
+	return comp
+}
+
+func assertHTML(t *testing.T, doc *MemDocument, want string) {
+	t.Helper()
+	if got := doc.HTML("#app"); got != want {
+		t.Errorf("rendered %q, expected %q", got, want)
+	}
+}
+
+func TestRender(t *testing.T) {
+	cases := []struct {
+		name string
+		tmpl string
+		want string
+	}{
+		{name: "text", tmpl: "<p>{{ Message }}</p>", want: "<p>hello</p>"},
+		{name: "bind", tmpl: `<p v-bind:title="Message"></p>`, want: `<p title="hello"></p>`},
+		{name: "if", tmpl: `<div><p v-if="Seen">seen</p><p v-if="!Seen">unseen</p></div>`, want: "<div><p>seen</p></div>"},
+		{name: "else if", tmpl: `<div><p v-if="!Seen">a</p><p v-else-if="Seen">b</p><p v-else>c</p></div>`, want: "<div><p>b</p></div>"},
+		{name: "else", tmpl: `<div><p v-if="!Seen">a</p> <p v-else>b</p></div>`, want: "<div> <p>b</p></div>"},
+		{name: "for", tmpl: `<ol><li v-for="Todo in Todos" v-bind:title="Todo.Text"></li></ol>`, want: `<ol><li title="a"></li><li title="b"></li></ol>`},
+		{name: "html", tmpl: `<p v-html="Message"></p>`, want: "<p>hello</p>"},
+		{name: "comment", tmpl: `<!-- todo --><p><!-- message -->{{ Message }}</p>`, want: "<p>hello</p>"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			data := &testData{Message: "hello", Seen: true, Todos: []testTodo{{"a"}, {"b"}}}
+			_, doc := newTestVM(t, c.tmpl, Data(data))
+			assertHTML(t, doc, c.want)
+		})
+	}
+}
+
+func TestSubcomponent(t *testing.T) {
+	tmpl := `<ol><todo-item v-for="Item in Todos" v-bind:Todo="Item"></todo-item></ol>`
+	data := &testData{Todos: []testTodo{{"a"}, {"b"}}}
+	vm, doc := newTestVM(t, tmpl, Data(data), Sub("todo-item", newTestComp(t,
+		Props("Todo"),
+		Template("<li>{{ Todo.Text }}</li>"),
+	)))
+	assertHTML(t, doc, "<ol><li>a</li><li>b</li></ol>")
+
+	vm.Set("Todos", []testTodo{{"c"}})
+	doc.Flush()
+	assertHTML(t, doc, "<ol><li>c</li></ol>")
+}
+
+func TestEvents(t *testing.T) {
+	reverse := func(vctx Context) {
+		data := vctx.Data().(*testData)
+		runes := []rune(data.Message)
+		for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+			runes[i], runes[j] = runes[j], runes[i]
+		}
+		data.Message = string(runes)
+	}
+	tmpl := `<div><p>{{ Message }}</p><button v-on:click="Reverse">Reverse</button><input v-model="Message"></div>`
+	_, doc := newTestVM(t, tmpl, Data(&testData{Message: "abc"}), Method("Reverse", reverse))
+
+	doc.Dispatch(doc.QuerySelector("button"), "click", MemEventInit{})
+	doc.Flush()
+	assertHTML(t, doc, `<div><p>cba</p><button>Reverse</button><input value="cba"/></div>`)
+
+	input := doc.QuerySelector("input")
+	input.SetProperty("value", "xyz")
+	doc.Dispatch(input, "input", MemEventInit{})
+	doc.Flush()
+	assertHTML(t, doc, `<div><p>xyz</p><button>Reverse</button><input value="xyz"/></div>`)
+}
+
+func TestEventModifiers(t *testing.T) {
+	cases := []struct {
+		name      string
+		tmpl      string
+		target    string
+		typ       string
+		init      MemEventInit
+		want      []string
+		prevented bool
+	}{
+		{"bubble", `<section v-on:click="Outer"><button v-on:click="Inner"></button></section>`, "button", "click", MemEventInit{}, []string{"Inner", "Outer"}, false},
+		{"stop", `<section v-on:click="Outer"><button v-on:click.stop="Inner"></button></section>`, "button", "click", MemEventInit{}, []string{"Inner"}, false},
+		{"capture", `<section v-on:click.capture="Outer"><button v-on:click="Inner"></button></section>`, "button", "click", MemEventInit{}, []string{"Outer", "Inner"}, false},
+		{"prevent", `<section><button v-on:click.prevent="Inner"></button></section>`, "button", "click", MemEventInit{}, []string{"Inner"}, true},
+		{"prevent without handler", `<form v-on:submit.prevent><button></button></form>`, "button", "submit", MemEventInit{}, nil, true},
+		{"passive", `<section><button v-on:click.passive.prevent="Inner"></button></section>`, "button", "click", MemEventInit{}, []string{"Inner"}, false},
+		{"self child", `<section v-on:click.self="Outer"><button></button></section>`, "button", "click", MemEventInit{}, nil, false},
+		{"self", `<section v-on:click.self="Outer"><button></button></section>`, "section", "click", MemEventInit{}, []string{"Outer"}, false},
+		{"ctrl", `<button v-on:click.ctrl="Inner"></button>`, "button", "click", MemEventInit{Ctrl: true, Shift: true}, []string{"Inner"}, false},
+		{"ctrl missing", `<button v-on:click.ctrl="Inner"></button>`, "button", "click", MemEventInit{Shift: true}, nil, false},
+		{"ctrl exact", `<button v-on:click.ctrl.exact="Inner"></button>`, "button", "click", MemEventInit{Ctrl: true, Shift: true}, nil, false},
+		{"exact", `<button v-on:click.exact="Inner"></button>`, "button", "click", MemEventInit{}, []string{"Inner"}, false},
+		{"right", `<button v-on:click.right="Inner"></button>`, "button", "click", MemEventInit{Button: 2}, []string{"Inner"}, false},
+		{"right missing", `<button v-on:click.right="Inner"></button>`, "button", "click", MemEventInit{}, nil, false},
+		{"key", `<input v-on:keyup.enter="Inner">`, "input", "keyup", MemEventInit{Key: "Enter"}, []string{"Inner"}, false},
+		{"key alias", `<input v-on:keyup.esc="Inner">`, "input", "keyup", MemEventInit{Key: "Escape"}, []string{"Inner"}, false},
+		{"key missing", `<input v-on:keyup.page-down.stop="Inner">`, "input", "keyup", MemEventInit{Key: "Enter"}, nil, false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var calls []string
+			record := func(name string) func(Context) {
+				return func(Context) { calls = append(calls, name) }
+			}
+			_, doc := newTestVM(t, `<div>`+c.tmpl+`</div>`,
+				Method("Outer", record("Outer")), Method("Inner", record("Inner")))
+
+			event := doc.Dispatch(doc.QuerySelector(c.target), c.typ, c.init)
+			if !reflect.DeepEqual(calls, c.want) {
+				t.Errorf("called %v, expected %v", calls, c.want)
+			}
+			if event.DefaultPrevented() != c.prevented {
+				t.Errorf("prevented %v, expected %v", event.DefaultPrevented(), c.prevented)
+			}
+		})
+	}
+
+	// Once handlers are only called by the first event.
+	var count int
+	_, doc := newTestVM(t, `<div><button v-on:click.once="Count"></button></div>`, Method("Count", func(Context) { count++ }))
+	doc.Dispatch(doc.QuerySelector("button"), "click", MemEventInit{})
+	doc.Dispatch(doc.QuerySelector("button"), "click", MemEventInit{})
+	if count != 1 {
+		t.Errorf("called %d times, expected once", count)
+	}
+
+	// Events of subcomponents bubble to the handlers of the parent, unless stopped.
+	var calls []string
+	record := func(name string) func(Context) {
+		return func(Context) { calls = append(calls, name) }
+	}
+	sub := newTestComp(t, Method("Inner", record("Inner")), Method("Stop", record("Stop")),
+		Template(`<p><button v-on:click="Inner"></button><a v-on:click.stop="Stop"></a></p>`))
+	_, doc = newTestVM(t, `<div><section v-on:click="Outer"><p-sub></p-sub></section></div>`,
+		Method("Outer", record("Outer")), Sub("p-sub", sub))
+	doc.Dispatch(doc.QuerySelector("button"), "click", MemEventInit{})
+	doc.Dispatch(doc.QuerySelector("a"), "click", MemEventInit{})
+	if want := []string{"Inner", "Outer", "Stop"}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("called %v, expected %v", calls, want)
+	}
+}
+
+func TestEventDispatch(t *testing.T) {
+	cases := []struct {
+		name   string
+		tmpl   string
+		target string
+		typ    string
+		init   MemEventInit
+		want   []string
+	}{
+		{"exact type", `<section clickable="Outer" v-on:clicked="Outer"><button v-on:click="Inner"></button></section>`, "button", "click", MemEventInit{}, []string{"Inner"}},
+		{"multiple handlers", `<button v-on:click="Inner" v-on:click.ctrl="Outer"></button>`, "button", "click", MemEventInit{Ctrl: true}, []string{"Inner", "Outer"}},
+		{"model and handler", `<input v-model="Message" v-on:input="Inner">`, "input", "input", MemEventInit{}, []string{"Inner"}},
+		{"non bubbling", `<section v-on:focus="Outer"><input v-on:focus="Inner"></section>`, "input", "focus", MemEventInit{}, []string{"Inner"}},
+		{"non bubbling capture", `<section v-on:focus.capture="Outer"><input v-on:focus="Inner"></section>`, "input", "focus", MemEventInit{}, []string{"Outer", "Inner"}},
+		{"non bubbling target", `<section v-on:mouseenter="Outer"><input v-on:mouseenter="Inner"></section>`, "section", "mouseenter", MemEventInit{}, []string{"Outer"}},
+		{"subcomponent root", `<p-sub v-on:click="Outer"></p-sub>`, "button", "click", MemEventInit{}, []string{"Inner", "Outer"}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var calls []string
+			record := func(name string) func(Context) {
+				return func(Context) { calls = append(calls, name) }
+			}
+			sub := newTestComp(t, Method("Inner", record("Inner")),
+				Template(`<p><button v-on:click="Inner"></button></p>`))
+			_, doc := newTestVM(t, `<div>`+c.tmpl+`</div>`, Data(&testData{}),
+				Method("Outer", record("Outer")), Method("Inner", record("Inner")), Sub("p-sub", sub))
+
+			doc.Dispatch(doc.QuerySelector(c.target), c.typ, c.init)
+			if !reflect.DeepEqual(calls, c.want) {
+				t.Errorf("called %v, expected %v", calls, c.want)
+			}
+		})
+	}
+}
+
+func TestEventArguments(t *testing.T) {
+	type removed struct {
+		todo  testTodo
+		index int64
+		n     uint8
+	}
+	var calls []interface{}
+	remove := func(vctx Context, todo testTodo, index int64, n uint8) {
+		calls = append(calls, removed{todo, index, n})
+	}
+	handle := func(vctx Context, event Event) {
+		calls = append(calls, event.Type())
+	}
+	typed := func(vctx Context, event Event, text string) {
+		calls = append(calls, event.Key()+text)
+	}
+	native := func(vctx Context, event *MemEvent) {
+		calls = append(calls, event.Key())
+	}
+	tmpl := `<div><ol><li v-for="(Todo, Index) in Todos" v-on:click="Remove(Todo, Index, 3)"></li></ol>` +
+		`<button v-on:click="Handle"></button><input v-on:keyup="Typed($event, Message)" v-on:keydown="Native">` +
+		`<textarea v-on:keydown="Native($event)"></textarea></div>`
+	_, doc := newTestVM(t, tmpl, Data(&testData{Message: "!", Todos: []testTodo{{"a"}, {"b"}}}),
+		Method("Remove", remove), Method("Handle", handle), Method("Typed", typed), Method("Native", native))
+
+	doc.Dispatch(doc.QuerySelector("ol").ChildNodes()[1], "click", MemEventInit{})
+	doc.Dispatch(doc.QuerySelector("button"), "click", MemEventInit{})
+	doc.Dispatch(doc.QuerySelector("input"), "keyup", MemEventInit{Key: "a"})
+	// Methods accepting the event of the backend receive the native event.
+	doc.Dispatch(doc.QuerySelector("input"), "keydown", MemEventInit{Key: "b"})
+	doc.Dispatch(doc.QuerySelector("textarea"), "keydown", MemEventInit{Key: "c"})
+	want := []interface{}{removed{testTodo{"b"}, 1, 3}, "click", "a!", "b", "c"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("called %v, expected %v", calls, want)
+	}
+
+	// Items of maps have no data paths, their values are bound to the handlers.
+	type mapData struct {
+		Todos map[string]testTodo
+	}
+	calls = nil
+	selected := func(vctx Context, text string, todo testTodo, key string) {
+		calls = append(calls, text, todo, key)
+	}
+	tmpl = `<ol><li v-for="(Todo, Key) in Todos" v-on:click="Select(Todo.Text, Todo, Key)"></li></ol>`
+	_, doc = newTestVM(t, tmpl, Data(&mapData{Todos: map[string]testTodo{"a": {"x"}, "b": {"y"}}}),
+		Method("Select", selected))
+	doc.Dispatch(doc.QuerySelector("ol").ChildNodes()[1], "click", MemEventInit{})
+	want = []interface{}{"y", testTodo{"y"}, "b"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("called %v, expected %v", calls, want)
+	}
+}
+
+func TestConvertArg(t *testing.T) {
+	tests := []struct {
+		arg  interface{}
+		typ  interface{}
+		want interface{}
+	}{
+		{arg: 3, typ: uint8(0), want: uint8(3)},
+		{arg: 2.0, typ: int64(0), want: int64(2)},
+		{arg: 1.5, typ: float32(0), want: float32(1.5)},
+		{arg: uint64(1), typ: 0, want: 1},
+		{arg: 1.5, typ: 0},
+		{arg: -1, typ: uint(0)},
+		{arg: 256, typ: uint8(0)},
+		{arg: uint64(1) << 63, typ: int64(0)},
+	}
+	for _, test := range tests {
+		typ := reflect.TypeOf(test.typ)
+		got, err := convertArg(test.arg, typ)
+		if test.want == nil {
+			if err == nil {
+				t.Errorf("expected error converting %v to %v, got %v", test.arg, typ, got)
+			}
+			continue
+		}
+		if err != nil || got.Interface() != test.want {
+			t.Errorf("converted %v to %v, got %v, %v, expected %v", test.arg, typ, got, err, test.want)
+		}
+	}
+}
+
+func TestExpressions(t *testing.T) {
+	cases := []struct {
+		name string
+		tmpl string
+		want string
+	}{
+		{name: "text", tmpl: `<p>{{ Message + "!" }} {{ len(Todos) }}</p>`, want: "<p>hello! 2</p>"},
+		{name: "if", tmpl: `<div><p v-if="Seen && len(Todos) > 1">many</p><p v-if="!Seen || Message == 'bye'">none</p></div>`, want: "<div><p>many</p></div>"},
+		{name: "bind", tmpl: `<p v-bind:title="Todos[len(Todos) - 1].Text"></p>`, want: `<p title="b"></p>`},
+		{name: "for", tmpl: `<ol><li v-for="Todo in Todos" v-if="Todo.Text != 'a'">{{ Todo.Text }}</li></ol>`, want: "<ol><li>b</li></ol>"},
+	}
+
+	count := Method("len", func(vctx Context, todos []testTodo) int {
+		return len(todos)
+	})
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			data := &testData{Message: "hello", Seen: true, Todos: []testTodo{{"a"}, {"b"}}}
+			_, doc := newTestVM(t, c.tmpl, Data(data), count)
+			assertHTML(t, doc, c.want)
+		})
+	}
+
+	remove := func(vctx Context, todo testTodo) {
+		data := vctx.Data().(*testData)
+		for i, item := range data.Todos {
+			if item == todo {
+				data.Todos = append(data.Todos[:i], data.Todos[i+1:]...)
+				return
+			}
+		}
+	}
+	tmpl := `<ol><li v-for="Todo in Todos" v-on:click="Remove(Todo)">{{ Todo.Text }}</li></ol>`
+	_, doc := newTestVM(t, tmpl, Data(&testData{Todos: []testTodo{{"a"}, {"b"}}}), Method("Remove", remove))
+	assertHTML(t, doc, `<ol><li>a</li><li>b</li></ol>`)
+
+	doc.Dispatch(doc.QuerySelector("li"), "click", MemEventInit{})
+	doc.Flush()
+	assertHTML(t, doc, `<ol><li>b</li></ol>`)
+}
+
+func TestShow(t *testing.T) {
+	type style struct {
+		Color string
+	}
+	tmpl := `<div><p v-show="Seen" style="margin: 0;" :style="Style">{{ Message }}</p><input v-show="!Seen"></div>`
+	vm, doc := newTestVM(t, tmpl, Data(&testData{Message: "a", Seen: true}), Computed("Style", func(Context) style {
+		return style{Color: "red"}
+	}))
+	assertHTML(t, doc, `<div><p style="margin: 0; color: red">a</p><input style="display: none"/></div>`)
+	input := doc.QuerySelector("input")
+
+	vm.Set("Seen", false)
+	doc.Flush()
+	assertHTML(t, doc, `<div><p style="margin: 0; color: red; display: none">a</p><input/></div>`)
+	if doc.QuerySelector("input") != input {
+		t.Errorf("expected shown element to be kept")
+	}
+}
+
+func TestLoops(t *testing.T) {
+	type loopData struct {
+		Inventory []string
+		Prices    map[string]int
+		Todo      testTodo
+		Queue     chan string
+		Quotes    map[string]int
+	}
+	cases := []struct {
+		name string
+		tmpl string
+		want string
+	}{
+		{name: "index", tmpl: `<p><i v-for="(Item, Index) in Inventory">{{ Index }}:{{ Item }} </i></p>`, want: "<p><i>0:a </i><i>1:b </i></p>"},
+		{name: "map", tmpl: `<p><i v-for="(Price, Name, Index) in Prices">{{ Index }}:{{ Name }}={{ Price }} </i></p>`, want: "<p><i>0:x=2 </i><i>1:y=1 </i></p>"},
+		{name: "map keys", tmpl: `<p><i v-for="(Price, Name) in Quotes">{{ Name }}={{ Price }} </i></p>`, want: "<p><i>a]b=1 </i></p>"},
+		{name: "range", tmpl: `<p><i v-for="N in 3">{{ N }}</i></p>`, want: "<p><i>1</i><i>2</i><i>3</i></p>"},
+		{name: "struct", tmpl: `<p><i v-for="(Value, Name) in Todo">{{ Name }}={{ Value }}</i></p>`, want: "<p><i>Text=t</i></p>"},
+		{name: "channel", tmpl: `<p><i v-for="Item in Queue">{{ Item }}</i></p>`, want: "<p><i>c</i><i>d</i></p>"},
+		{name: "nested", tmpl: `<p><b v-for="(Item, Index) in Inventory"><i v-for="Item in Index + 1">{{ Item }}</i></b></p>`, want: "<p><b><i>1</i></b><b><i>1</i><i>2</i></b></p>"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			queue := make(chan string, 2)
+			queue <- "c"
+			queue <- "d"
+			data := &loopData{
+				Inventory: []string{"a", "b"},
+				Prices:    map[string]int{"y": 1, "x": 2},
+				Todo:      testTodo{Text: "t"},
+				Queue:     queue,
+				Quotes:    map[string]int{"a]b": 1},
+			}
+			_, doc := newTestVM(t, c.tmpl, Data(data))
+			assertHTML(t, doc, c.want)
+		})
+	}
+
+	// The values received from channels are kept by later renders.
+	queue := make(chan string, 2)
+	queue <- "c"
+	vm, doc := newTestVM(t, `<p><i v-for="Item in Queue">{{ Item }}</i></p>`, Data(&loopData{Queue: queue}))
+	queue <- "d"
+	vm.Set("Inventory", []string{"a"})
+	doc.Flush()
+	assertHTML(t, doc, "<p><i>c</i><i>d</i></p>")
+
+	// The values are released once the channel is no longer iterated.
+	vm.Set("Queue", make(chan string))
+	doc.Flush()
+	assertHTML(t, doc, "<p></p>")
+	if len(vm.received) != 0 {
+		t.Errorf("expected received values to be released, got %v", vm.received)
+	}
+
+	var removed int
+	remove := Method("Remove", func(vctx Context, index int) {
+		removed = index
+	})
+	tmpl := `<ol><li v-for="(Item, Index) in Inventory" v-on:click="Remove(Index)">{{ Item }}</li></ol>`
+	_, doc = newTestVM(t, tmpl, Data(&loopData{Inventory: []string{"a", "b"}}), remove)
+	assertHTML(t, doc, `<ol><li>a</li><li>b</li></ol>`)
+
+	doc.Dispatch(doc.QuerySelector("ol").ChildNodes()[1], "click", MemEventInit{})
+	if removed != 1 {
+		t.Errorf("expected removed index 1, got %d", removed)
+	}
+
+	for _, value := range []string{"Item", "Item of Inventory", "(Item, 0) in Inventory", "Item inInventory", "(A, B, C, D) in Inventory"} {
+		if _, err := parseFor(value); err == nil {
+			t.Errorf("expected error parsing loop %q", value)
+		}
+	}
+}
+
+func TestScope(t *testing.T) {
+	type group struct {
+		Name  string
+		Items []string
+	}
+	type scopeData struct {
+		Item      string
+		ItemCount int
+		Groups    []group
+	}
+	tmpl := `<div><p v-for="Item in Groups" :title="Item.Name">Item {{ Item.Name }} of {{ ItemCount }}:` +
+		`<i v-for="Item in Item.Items">{{ Item }}</i></p><b>{{ Item }}</b></div>`
+	data := &scopeData{Item: "outside", ItemCount: 2, Groups: []group{{"a", []string{"x", "y"}}, {"b", nil}}}
+	_, doc := newTestVM(t, tmpl, Data(data))
+	assertHTML(t, doc, `<div><p title="a">Item a of 2:<i>x</i><i>y</i></p><p title="b">Item b of 2:</p><b>outside</b></div>`)
+}
+
+type testLevel int
+
+func (level testLevel) MarshalText() ([]byte, error) {
+	return []byte(strings.Repeat("*", int(level))), nil
+}
+
+func (level *testLevel) UnmarshalText(text []byte) error {
+	*level = testLevel(len(text))
+	return nil
+}
+
+func TestModel(t *testing.T) {
+	type modelData struct {
+		Done   bool
+		Tags   []string
+		Pick   string
+		Sizes  []string
+		Count  int
+		Level  testLevel
+		Name   string
+		Amount interface{}
+	}
+	tmpl := `<form>` +
+		`<input type="checkbox" v-model="Done">` +
+		`<input type="checkbox" value="a" v-model="Tags"><input type="checkbox" value="b" v-model="Tags">` +
+		`<input type="radio" value="x" v-model="Pick"><input type="radio" value="y" v-model="Pick">` +
+		`<select v-model="Pick"><option>x</option><option value="y">Y</option></select>` +
+		`<select multiple v-model="Sizes"><option v-for="Size in Tags" :value="Size">{{ Size }}</option></select>` +
+		`<input id="count" v-model="Count"><input id="level" v-model="Level">` +
+		`<input id="name" v-model.lazy.trim="Name"><input id="amount" v-model.number="Amount">` +
+		`</form>`
+	data := &modelData{Tags: []string{"a"}, Pick: "y", Sizes: []string{"a"}, Count: 1, Level: 2}
+	vm, doc := newTestVM(t, tmpl, Data(data))
+	assertHTML(t, doc, `<form><input type="checkbox"/>`+
+		`<input type="checkbox" value="a" checked=""/><input type="checkbox" value="b"/>`+
+		`<input type="radio" value="x"/><input type="radio" value="y" checked=""/>`+
+		`<select><option>x</option><option value="y" selected="">Y</option></select>`+
+		`<select multiple=""><option value="a" selected="">a</option></select>`+
+		`<input id="count" value="1"/><input id="level" value="**"/>`+
+		`<input id="name" value=""/><input id="amount" value=""/>`+
+		`</form>`)
+
+	inputs := doc.QuerySelector("form").ChildNodes()
+	inputs[0].SetProperty("checked", true)
+	doc.Dispatch(inputs[0], "change", MemEventInit{})
+	inputs[2].SetProperty("checked", true)
+	doc.Dispatch(inputs[2], "change", MemEventInit{})
+	inputs[3].SetProperty("checked", true)
+	doc.Dispatch(inputs[3], "change", MemEventInit{})
+	doc.Flush()
+	options := inputs[6].ChildNodes()
+	options[1].SetProperty("selected", true)
+	doc.Dispatch(inputs[6], "change", MemEventInit{})
+
+	for _, input := range []struct {
+		id, typ, value string
+	}{
+		{id: "#count", typ: "input", value: " 42"},
+		{id: "#level", typ: "input", value: "****"},
+		{id: "#name", typ: "change", value: " Ada "},
+		{id: "#amount", typ: "input", value: "1.5"},
+	} {
+		node := doc.QuerySelector(input.id)
+		node.SetProperty("value", input.value)
+		doc.Dispatch(node, input.typ, MemEventInit{})
+	}
+	doc.Flush()
+
+	want := &modelData{Done: true, Tags: []string{"a", "b"}, Pick: "x", Sizes: []string{"a", "b"}, Count: 42, Level: 4, Name: "Ada", Amount: 1.5}
+	if got := vm.Data(); !reflect.DeepEqual(got, want) {
+		t.Errorf("set model to %+v, expected %+v", got, want)
+	}
+
+	var handled []error
+	tmpl = `<input v-model="Count">`
+	_, doc = newTestVM(t, tmpl, Data(&modelData{}), ErrorHandler(func(vctx Context, err error) {
+		handled = append(handled, err)
+	}))
+	input := doc.QuerySelector("input")
+	input.SetProperty("value", "abc")
+	doc.Dispatch(input, "input", MemEventInit{})
+	if len(handled) != 1 {
+		t.Errorf("expected parse error, got %v", handled)
+	}
+
+	// The number modifier is rejected on fields which are not numbers.
+	for _, tmpl := range []string{`<input v-model.number="Name">`, `<input type="checkbox" value="1" v-model.number="Tags">`} {
+		_, err := Component(Template(tmpl), Data(&modelData{}))
+		var fieldErr *FieldError
+		if !errors.As(err, &fieldErr) || fieldErr.Err != errNumberType {
+			t.Errorf("expected number modifier error for %s, got %v", tmpl, err)
+		}
+	}
+	newTestComp(t, Template(`<input v-model.number="Count">`), Data(&modelData{}))
+}
+
+func TestSubcomponentModel(t *testing.T) {
+	upper := func(vctx Context) {
+		vctx.Emit("update:Value", strings.ToUpper(vctx.Get("Value").(string)))
+	}
+	sub := newTestComp(t, Props("Value"), Method("Upper", upper),
+		Template(`<button v-on:click="Upper">{{ Value }}</button>`))
+	tmpl := `<div><p>{{ Message }}</p><text-input v-for="Todo in Todos" v-model="Todo.Text"></text-input></div>`
+	_, doc := newTestVM(t, tmpl, Data(&testData{Message: "m", Todos: []testTodo{{"a"}, {"b"}}}), Sub("text-input", sub))
+	assertHTML(t, doc, `<div><p>m</p><button>a</button><button>b</button></div>`)
+
+	// The update event sets the field bound to the instance which emitted it.
+	doc.Dispatch(doc.QuerySelector("#app").ChildNodes()[0].ChildNodes()[2], "click", MemEventInit{})
+	doc.Flush()
+	assertHTML(t, doc, `<div><p>m</p><button>a</button><button>B</button></div>`)
+
+	// Named models bind other props.
+	title := func(vctx Context) {
+		vctx.Emit("update:Title", "t")
+	}
+	sub = newTestComp(t, Props("Title"), Method("Rename", title),
+		Template(`<h1 v-on:click="Rename">{{ Title }}</h1>`))
+	_, doc = newTestVM(t, `<div><p-title v-model:title="Message"></p-title></div>`,
+		Data(&testData{Message: "m"}), Sub("p-title", sub))
+	doc.Dispatch(doc.QuerySelector("h1"), "click", MemEventInit{})
+	doc.Flush()
+	assertHTML(t, doc, `<div><h1>t</h1></div>`)
+}
+
+func TestSubcomponentEvents(t *testing.T) {
+	var removed []interface{}
+	var handled []error
+	remove := func(vctx Context) {
+		vctx.Emit("remove", vctx.Get("Todo"))
+	}
+	sub := newTestComp(t, Props("Todo"), Emits("remove"), Method("Remove", remove),
+		Method("Other", func(vctx Context) { vctx.Emit("other") }),
+		Template(`<li><button v-on:click="Remove">{{ Todo.Text }}</button><a v-on:click="Other"></a></li>`))
+	tmpl := `<ol><todo-item v-for="(Item, Index) in Todos" :todo="Item" v-on:remove="Removed(Index, $event)" v-on:click="Clicked"></todo-item></ol>`
+	_, doc := newTestVM(t, tmpl, Data(&testData{Message: "m", Todos: []testTodo{{"a"}, {"b"}}}), Sub("todo-item", sub),
+		Method("Removed", func(vctx Context, index int, todo testTodo) {
+			removed = append(removed, index, todo.Text)
+		}),
+		Method("Clicked", func(vctx Context) {
+			removed = append(removed, "click")
+		}),
+		ErrorHandler(func(vctx Context, err error) {
+			handled = append(handled, err)
+		}))
+
+	// Only the handler of the instance which emitted the event is called,
+	// undeclared events fall through to the root element of the instance.
+	doc.Dispatch(doc.QuerySelector("ol").ChildNodes()[1].ChildNodes()[0], "click", MemEventInit{})
+	doc.Flush()
+	if want := []interface{}{1, "b", "click"}; !reflect.DeepEqual(removed, want) {
+		t.Errorf("called %v, expected %v", removed, want)
+	}
+
+	// Emitting undeclared events is an error.
+	doc.Dispatch(doc.QuerySelector("a"), "click", MemEventInit{})
+	if len(handled) != 1 || !strings.Contains(handled[0].Error(), "undeclared event: other") {
+		t.Errorf("handled %v, expected undeclared event error", handled)
+	}
+}
+
+func TestBus(t *testing.T) {
+	var calls []string
+	var handled []error
+	status := newTestComp(t, Template(`<p>status</p>`),
+		Created(func(vctx Context) {
+			vctx.Bus().On("auth.changed", "Changed")
+			vctx.Bus().On("ws.*", "Any")
+			vctx.Bus().Once("*", "Any")
+			vctx.Bus().On("stop", "Stop")
+		}),
+		Method("Stop", func(vctx Context) {
+			vctx.Bus().Off("ws.*", "Any")
+		}),
+		Method("Changed", func(vctx Context, user string) {
+			calls = append(calls, "changed "+user)
+		}),
+		Method("Any", func(vctx Context, n int) {
+			calls = append(calls, "any "+strconv.Itoa(n))
+		}),
+		ErrorHandler(func(vctx Context, err error) {
+			handled = append(handled, err)
+		}))
+	tmpl := `<div><auth-status v-if="Seen"></auth-status></div>`
+	vm, doc := newTestVM(t, tmpl, Data(&testData{Seen: true}), Sub("auth-status", status))
+	bus := vm.Bus()
+	assertCalls := func(want ...string) {
+		t.Helper()
+		if !reflect.DeepEqual(calls, want) {
+			t.Errorf("called %v, expected %v", calls, want)
+		}
+		calls = nil
+	}
+
+	// Wildcards match any segment, once subscriptions are called once.
+	bus.Pub("ws.message.text", 1)
+	assertCalls("any 1", "any 1")
+	bus.Pub("ws.open", 2)
+	assertCalls("any 2")
+	bus.Pub("auth.changed", "bob")
+	assertCalls("changed bob")
+
+	// Payloads of other types are passed to the error handler of the subscriber.
+	bus.Pub("auth.changed", 3)
+	assertCalls()
+	if len(handled) != 1 || !strings.Contains(handled[0].Error(), "topic auth.changed: argument 1 of method Changed") {
+		t.Errorf("handled %v, expected payload error", handled)
+	}
+
+	// Subscriptions are removed by unsubscribing and by releasing the component.
+	bus.Pub("stop")
+	bus.Pub("ws.open", 4)
+	assertCalls()
+	vm.Set("Seen", false)
+	doc.Flush()
+	bus.Pub("auth.changed", "alice")
+	assertCalls()
+}
+
+type testProps struct {
+	Todo  testTodo `required:"true"`
+	Title string   `default:"untitled" validator:"ValidTitle"`
+	Count int64    `default:"1"`
+}
+
+func (props testProps) ValidTitle(title string) error {
+	if title == "" {
+		return errors.New("empty title")
+	}
+	return nil
+}
+
+func TestTypedProps(t *testing.T) {
+	var props []testProps
+	var handled []error
+	sub := newTestComp(t, PropsOf(testProps{}), Template(`<li>{{ Title }} {{ Count }} {{ Todo.Text }}</li>`),
+		Created(func(vctx Context) {
+			props = append(props, vctx.Props().(testProps))
+		}))
+	tmpl := `<ol><todo-item :todo="Todos[0]" :count="3"></todo-item><todo-item :title="Message" :count="Message"></todo-item></ol>`
+	_, doc := newTestVM(t, tmpl, Data(&testData{Todos: []testTodo{{"a"}}}), Sub("todo-item", sub),
+		ErrorHandler(func(vctx Context, err error) {
+			handled = append(handled, err)
+		}))
+
+	// Props are converted to the types of the fields, missing props are set to their defaults.
+	assertHTML(t, doc, `<ol><li>untitled 3 a</li><li> 1 </li></ol>`)
+	if want := (testProps{Todo: testTodo{"a"}, Title: "untitled", Count: 3}); len(props) != 2 || props[0] != want {
+		t.Errorf("got props %v, expected %v", props, want)
+	}
+
+	// Missing required props, props of other types and failed validators are passed to the error handler.
+	want := []string{
+		"prop Todo of subcomponent todo-item: missing required prop",
+		"prop Count of subcomponent todo-item: value of type string is not assignable to int64",
+		"prop Title of subcomponent todo-item: empty title",
+	}
+	if len(handled) != len(want) {
+		t.Fatalf("handled %v, expected %d errors", handled, len(want))
+	}
+	for i, err := range handled {
+		if !strings.Contains(err.Error(), want[i]) {
+			t.Errorf("handled %q, expected %q", err, want[i])
+		}
+	}
+
+	// Invalid declarations are diagnosed.
+	type invalidProps struct {
+		Done  bool     `default:"maybe"`
+		Tags  []string `default:"a"`
+		Title string   `validator:"Missing"`
+		Todo  testTodo `validator:"ValidTitle"`
+	}
+	_, err := Component(PropsOf(invalidProps{}))
+	var diags Diagnostics
+	if !errors.As(err, &diags) || len(diags) != 4 {
+		t.Errorf("got %v, expected 4 diagnostics", err)
+	}
+}
+
+func TestRawHtml(t *testing.T) {
+	called := false
+	tmpl := `<div v-html="Message">{{ Message }}</div>`
+	vm, doc := newTestVM(t, tmpl, Data(&testData{Message: `<b v-if="Seen">{{ Bump() }}</b>`}),
+		Method("Bump", func(vctx Context) { called = true }))
+
+	// Expressions of the raw html are not executed, and its texts are not compiled.
+	assertHTML(t, doc, `<div><b v-if="Seen">{{ Bump() }}</b></div>`)
+	if called {
+		t.Error("expected the method of the raw html not to be called")
+	}
+	if n := len(vm.comp.prog.texts); n != 1 {
+		t.Errorf("compiled %d texts, expected only the text of the template", n)
+	}
+}
+
+func TestKeyed(t *testing.T) {
+	tmpl := `<ul><li v-for="Todo in Todos" :key="Todo.Text">{{ Message }}</li></ul>`
+	data := &testData{Todos: []testTodo{{"a"}, {"b"}, {"c"}}}
+	vm, doc := newTestVM(t, tmpl, Data(data))
+	first := doc.QuerySelector("li")
+
+	vm.Set("Todos", []testTodo{{"d"}, {"c"}, {"a"}})
+	doc.Flush()
+	if nodes := doc.QuerySelector("ul").ChildNodes(); len(nodes) != 3 || nodes[2] != first {
+		t.Errorf("expected keyed element to be moved, got %v", nodes)
+	}
+	assertHTML(t, doc, "<ul><li></li><li></li><li></li></ul>")
+
+	sub := newTestComp(t, Props("Todo"), Template(`<li>{{ Todo.Text }}</li>`))
+	tmpl = `<ul><todo-item v-for="Item in Todos" :key="Item.Text" v-bind:Todo="Item"></todo-item></ul>`
+	vm, doc = newTestVM(t, tmpl, Data(&testData{Todos: []testTodo{{"a"}, {"b"}}}), Sub("todo-item", sub))
+	first = doc.QuerySelector("li")
+
+	vm.Set("Todos", []testTodo{{"c"}, {"a"}})
+	doc.Flush()
+	if nodes := doc.QuerySelector("ul").ChildNodes(); len(nodes) != 2 || nodes[1] != first {
+		t.Errorf("expected keyed subcomponent to be moved, got %v", nodes)
+	}
+	assertHTML(t, doc, "<ul><li>c</li><li>a</li></ul>")
+}
+
+func TestMemDocumentRelease(t *testing.T) {
+	vm, doc := newTestVM(t, `<ol><li v-for="Todo in Todos">{{ Todo.Text }}</li></ol>`, Data(&testData{}))
+	before := len(doc.nodes)
+	vm.Set("Todos", []testTodo{{"a"}, {"b"}})
+	doc.Flush()
+	vm.Set("Todos", []testTodo(nil))
+	doc.Flush()
+	if after := len(doc.nodes); after != before {
+		t.Errorf("expected the wrappers of removed nodes to be released, got %d nodes, expected %d", after, before)
+	}
+
+	// Nodes which are inserted again keep their wrappers.
+	ol := doc.QuerySelector("ol")
+	li := doc.CreateElement("li")
+	li.AppendChild(doc.CreateTextNode("c"))
+	text := li.ChildNodes()[0]
+	ol.AppendChild(li)
+	ol.RemoveChild(li)
+	ol.AppendChild(li)
+	if got := ol.ChildNodes()[0]; got != li || got.ChildNodes()[0] != text {
+		t.Error("expected the wrappers of inserted nodes to be kept")
+	}
+
+	// Children replaced by the text content are released like removed nodes.
+	before = len(doc.nodes)
+	ol.SetTextContent("none")
+	if after := len(doc.nodes); after != before-2 {
+		t.Errorf("expected the wrappers of replaced children to be released, got %d nodes, expected %d", after, before-2)
+	}
+	ol.AppendChild(li)
+	if got := ol.ChildNodes()[1]; got != li || got.ChildNodes()[0] != text {
+		t.Error("expected the wrappers of replaced children to be kept")
+	}
+}
+
+func TestLongestIncreasing(t *testing.T) {
+	got := longestIncreasing([]int{2, -1, 0, 1, 5, 3, 4})
+	want := []int{2, 3, 5, 6}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, expected %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, expected %v", got, want)
+		}
+	}
+}
+
+func TestCompile(t *testing.T) {
+	tmpl := `<ol><li v-for="Todo in Todos" :title="Todo.Text">{{ Message }}</li></ol>`
+	comp := newTestComp(t, Template(tmpl), Data(&testData{}))
+	before := len(comp.prog.texts)
+
+	data := &testData{Message: "hello", Todos: []testTodo{{"a"}, {"b"}}}
+	for i := 0; i < 2; i++ {
+		got, err := RenderToString(comp, data)
+		if err != nil {
+			t.Fatalf("failed to render: %v", err)
+		}
+		if want := `<ol><li title="a">hello</li><li title="b">hello</li></ol>`; got != want {
+			t.Errorf("rendered %q, expected %q", got, want)
+		}
+	}
+	if after := len(comp.prog.texts); after != before {
+		t.Errorf("expected %d compiled texts, got %d", before, after)
+	}
+
+	// Rendered handlers and loops are cached, they are not parsed again by events and renders.
+	tmpl = `<ol><li v-for="Todo in Todos" v-on:click="Remove(Todo)">{{ Todo.Text }}</li></ol>`
+	vm, doc := newTestVM(t, tmpl, Data(data), Method("Remove", func(vctx Context, todo testTodo) {}))
+	prog := vm.comp.prog
+	if _, ok := prog.exprs["Remove(Todos[1])"]; !ok {
+		t.Errorf("expected rendered handler to be cached, got %v", prog.exprs)
+	}
+	exprs, loops := len(prog.exprs), len(prog.loops)
+	doc.Dispatch(doc.QuerySelector("ol").ChildNodes()[1], "click", MemEventInit{})
+	doc.Flush()
+	if len(prog.exprs) != exprs || len(prog.loops) != loops || loops != 1 {
+		t.Errorf("expected %d expressions and 1 loop, got %d and %d", exprs, len(prog.exprs), len(prog.loops))
+	}
+
+	for _, tmpl := range []string{`<p>{{{ RawHtml }}}</p>`, `<p>{{# Todos }}a{{/ Todos }}</p>`, `<p>{{> item }}</p>`} {
+		_, err := Component(Template(tmpl))
+		var tmplErr *TemplateError
+		if !errors.As(err, &tmplErr) || !strings.Contains(err.Error(), "mustache") {
+			t.Errorf("got %v, expected a template error for mustache syntax of %s", err, tmpl)
+		}
+	}
+}
+
+func TestComputedDependencies(t *testing.T) {
+	counts := make(map[string]int)
+	reversed := func(vctx Context) string {
+		counts["Reversed"]++
+		runes := []rune(vctx.Get("Message").(string))
+		for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+			runes[i], runes[j] = runes[j], runes[i]
+		}
+		return string(runes)
+	}
+	shout := func(vctx Context) string {
+		counts["Shout"]++
+		return vctx.Get("Reversed").(string) + "!"
+	}
+	count := func(vctx Context) int {
+		counts["Count"]++
+		return len(vctx.Get("Todos").([]testTodo))
+	}
+	toggle := func(vctx Context) {
+		data := vctx.Data().(*testData)
+		data.Seen = !data.Seen
+	}
+
+	tmpl := `<p>{{ Shout }} {{ Count }}</p>`
+	vm, doc := newTestVM(t, tmpl, Data(&testData{Message: "abc"}),
+		Computed("Reversed", reversed), Computed("Shout", shout), Computed("Count", count),
+		Method("Toggle", toggle))
+	assertHTML(t, doc, "<p>cba! 0</p>")
+
+	vm.Set("Seen", true)
+	doc.Flush()
+	if counts["Reversed"] != 1 || counts["Shout"] != 1 || counts["Count"] != 1 {
+		t.Errorf("expected computed to be cached after unrelated set, got %v", counts)
+	}
+
+	vm.Set("Message", "xyz")
+	doc.Flush()
+	assertHTML(t, doc, "<p>zyx! 0</p>")
+	if counts["Reversed"] != 2 || counts["Shout"] != 2 || counts["Count"] != 1 {
+		t.Errorf("expected dependent computed to be evaluated after set, got %v", counts)
+	}
+
+	vm.call("Toggle", nil)
+	doc.Flush()
+	if counts["Reversed"] != 2 || counts["Shout"] != 2 || counts["Count"] != 2 {
+		t.Errorf("expected only computed with mutable dependencies to be evaluated after method, got %v", counts)
+	}
+}
+
+func TestComputedUntracked(t *testing.T) {
+	type counterData struct {
+		Count int
+	}
+	double := func(vctx Context) int {
+		return vctx.Data().(*counterData).Count * 2
+	}
+	label := func(vctx Context) string {
+		return "double " + strconv.Itoa(vctx.Get("Double").(int))
+	}
+	vm, doc := newTestVM(t, `<p>{{ Double }} {{ Label }}</p>`, Data(&counterData{Count: 1}),
+		Computed("Double", double), Computed("Label", label))
+	assertHTML(t, doc, "<p>2 double 2</p>")
+
+	vm.Set("Count", 5)
+	doc.Flush()
+	assertHTML(t, doc, "<p>10 double 10</p>")
+}
+
+func TestComputedError(t *testing.T) {
+	var handled []error
+	checked := func(vctx Context) string {
+		if !vctx.Get("Seen").(bool) {
+			vctx.Get("Missing")
+		}
+		return vctx.Get("Message").(string)
+	}
+	vm, doc := newTestVM(t, `<p>{{ Checked }}</p>`, Data(&testData{Message: "a"}), Computed("Checked", checked),
+		ErrorHandler(func(vctx Context, err error) {
+			handled = append(handled, err)
+		}))
+	if len(handled) != 1 || vm.tracking != nil {
+		t.Fatalf("handled %v, expected the computed to stop tracking after an error", handled)
+	}
+
+	// The computed is evaluated again once its dependencies are set.
+	vm.Set("Seen", true)
+	doc.Flush()
+	assertHTML(t, doc, "<p>a</p>")
+	if len(handled) != 1 {
+		t.Errorf("handled %v, expected a single error", handled)
+	}
+}
+
+func TestScheduler(t *testing.T) {
+	renders := 0
+	tmpl := `<p>{{ Message }} {{ Renders }}</p>`
+	vm, doc := newTestVM(t, tmpl, Data(&testData{Message: "a"}), Computed("Renders", func(vctx Context) int {
+		vctx.Get("Message")
+		renders++
+		return renders
+	}))
+
+	var ticked string
+	vm.Set("Message", "b")
+	vm.Set("Message", "c")
+	vm.NextTick(func() {
+		ticked = doc.HTML("#app")
+	})
+	assertHTML(t, doc, "<p>a 1</p>")
+	if ticked != "" {
+		t.Errorf("expected next tick to be called after flush")
+	}
+
+	doc.Flush()
+	assertHTML(t, doc, "<p>c 2</p>")
+	if ticked != "<p>c 2</p>" {
+		t.Errorf("expected next tick to be called after render, got %q", ticked)
+	}
+}
+
+func TestReentrantEvents(t *testing.T) {
+	doc := NewMemDocument(`<div id="app"></div>`)
+	mounted := func(vctx Context) {
+		// The event is dispatched while the update loop is locked by the hook.
+		doc.Dispatch(doc.QuerySelector("button"), "click", MemEventInit{})
+	}
+	done := make(chan error)
+	go func() {
+		_, err := New(Backend(doc), El("#app"), Data(&testData{}), Mounted(mounted),
+			Method("See", func(vctx Context) { vctx.Set("Seen", true) }),
+			Template(`<button v-on:click="See">{{ Seen }}</button>`))
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("failed to create view model: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("dispatching an event from a hook deadlocked")
+	}
+
+	doc.Flush()
+	assertHTML(t, doc, "<button>true</button>")
+}
+
+func TestReentrantViewModel(t *testing.T) {
+	var vm *ViewModel
+	var ticked bool
+	see := func(vctx Context) {
+		// The captured view model is called on the update loop.
+		vm.Set("Seen", !vm.Get("Seen").(bool))
+		vm.Set("Message", vm.Data().(*testData).Message+"!")
+		vm.Bus().Pub("seen")
+		vm.NextTick(func() { ticked = true })
+	}
+	doc := NewMemDocument(`<div id="app"></div>`)
+	vm, err := New(Backend(doc), El("#app"), Data(&testData{Message: "a"}), Method("See", see),
+		Template(`<button v-on:click="See">{{ Message }} {{ Seen }}</button>`))
+	if err != nil {
+		t.Fatalf("failed to create view model: %v", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		doc.Dispatch(doc.QuerySelector("button"), "click", MemEventInit{})
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("calling the view model from a method deadlocked")
+	}
+
+	doc.Flush()
+	assertHTML(t, doc, "<button>a! true</button>")
+	if !ticked {
+		t.Error("expected next tick to be called")
+	}
+}
+
+func TestConcurrentMutation(t *testing.T) {
+	done := make(chan struct{})
+	async := func(vctx Context, message string) {
+		defer func() { done <- struct{}{} }()
+		vctx.Set("Message", message)
+		vctx.Set("Seen", !vctx.Get("Seen").(bool))
+	}
+	tmpl := `<div><p>{{ Message }}</p><input v-model="Message"></div>`
+	vm, doc := newTestVM(t, tmpl, Data(&testData{}), Method("Async", async))
+
+	const n = 10
+	for i := 0; i < n; i++ {
+		vm.Go("Async", "async")
+	}
+	input := doc.QuerySelector("input")
+	for i := 0; i < n; i++ {
+		vm.Set("Message", "set")
+		doc.Flush()
+	}
+	for i := 0; i < n; i++ {
+		<-done
+	}
+
+	input.SetProperty("value", "done")
+	doc.Dispatch(input, "input", MemEventInit{})
+	doc.Flush()
+	assertHTML(t, doc, `<div><p>done</p><input value="done"/></div>`)
+}
+
+func TestHooks(t *testing.T) {
+	var calls []string
+	hooks := func(name string) []Option {
+		hook := func(event string) func(Context) {
+			return func(vctx Context) {
+				calls = append(calls, name+"."+event)
+			}
+		}
+		return []Option{
+			Created(hook("created")),
+			Mounted(hook("mounted")),
+			Updated(hook("updated")),
+			BeforeUnmount(hook("beforeUnmount")),
+			Unmounted(hook("unmounted")),
+		}
+	}
+	assertCalls := func(want ...string) {
+		t.Helper()
+		if len(calls) != len(want) {
+			t.Fatalf("called %v, expected %v", calls, want)
+		}
+		for i := range want {
+			if calls[i] != want[i] {
+				t.Fatalf("called %v, expected %v", calls, want)
+			}
+		}
+		calls = nil
+	}
+
+	sub := newTestComp(t, append(hooks("sub"), Template("<p>sub</p>"))...)
+	tmpl := `<div><p-sub v-if="Seen"></p-sub><p>{{ Message }}</p></div>`
+	options := append(hooks("app"), Data(&testData{Seen: true}), Sub("p-sub", sub))
+	vm, doc := newTestVM(t, tmpl, options...)
+	assertCalls("app.created", "sub.created", "sub.mounted", "app.mounted")
+
+	vm.Set("Message", "hello")
+	doc.Flush()
+	assertCalls("sub.updated", "app.updated")
+
+	vm.Set("Seen", false)
+	doc.Flush()
+	assertCalls("sub.beforeUnmount", "sub.unmounted", "app.updated")
+	assertHTML(t, doc, "<div><p>hello</p></div>")
+}
+
+func TestErrors(t *testing.T) {
+	invalid := []struct {
+		name    string
+		options []Option
+	}{
+		{name: "attribute", options: []Option{Template(`<p v-unknown="Message"></p>`)}},
+		{name: "loop", options: []Option{Template(`<p v-for="Todos"></p>`)}},
+		{name: "element", options: []Option{Template(`text`)}},
+		{name: "method", options: []Option{Template(`<p></p>`), Method("Bad", func() {})}},
+		{name: "computed", options: []Option{Template(`<p></p>`), Computed("Bad", func(Context) {})}},
+		{name: "watcher", options: []Option{Template(`<p></p>`), Watch("Message", func(Context, string) {})}},
+		{name: "field", options: []Option{Template(`<p>{{ Missing }}</p>`), Data(&testData{})}},
+		{name: "slice", options: []Option{Template(`<p v-for="Todo in Message"></p>`), Data(&testData{})}},
+		{name: "item", options: []Option{Template(`<p v-for="Todo in Todos" :title="Todo.Missing"></p>`), Data(&testData{})}},
+		{name: "model", options: []Option{Template(`<input v-model="Seen">`), Data(&testData{})}},
+		{name: "on", options: []Option{Template(`<p v-on:click="Missing"></p>`)}},
+		{name: "else", options: []Option{Template(`<div><p v-if="Seen"></p><span></span><p v-else></p></div>`), Data(&testData{})}},
+		{name: "else value", options: []Option{Template(`<div><p v-if="Seen"></p><p v-else="Seen"></p></div>`), Data(&testData{})}},
+		{name: "expression", options: []Option{Template(`<p v-if="Seen &&"></p>`), Data(&testData{})}},
+		{name: "call", options: []Option{Template(`<p>{{ Missing(Message) }}</p>`), Data(&testData{})}},
+		{name: "text", options: []Option{Template(`<p>{{ Message</p>`), Data(&testData{})}},
+	}
+	for _, c := range invalid {
+		if _, err := Component(c.options...); err == nil {
+			t.Errorf("%s: expected component error", c.name)
+		}
+	}
+
+	var templateErr *TemplateError
+	_, err := Component(Template(`<div><ol><li v-unknown="Todos"></li></ol></div>`))
+	if !errors.As(err, &templateErr) || templateErr.Path != "div > ol > li" || templateErr.Attr != "v-unknown" {
+		t.Errorf("expected template error with location, got %v", err)
+	}
+
+	_, err = Component(Template(`<div><p v-if="Missing">{{ Message }}</p></div>`), Data(&testData{}))
+	if diags, ok := err.(Diagnostics); !ok || len(diags) != 1 {
+		t.Errorf("expected diagnostics for unknown field, got %v", err)
+	}
+
+	if _, err := New(Backend(NewMemDocument("")), El("#app"), Template("<p></p>")); err == nil {
+		t.Errorf("expected error for missing element")
+	}
+
+	var handled []error
+	handler := ErrorHandler(func(vctx Context, err error) {
+		handled = append(handled, err)
+	})
+	tmpl := `<div><p>{{ Message }}</p><ol><li v-for="Todo in Any"></li></ol></div>`
+	vm, doc := newTestVM(t, tmpl, Data(&testData{Message: "a"}), handler, Computed("Any", func(Context) interface{} {
+		return "a"
+	}))
+
+	var fieldErr *FieldError
+	if len(handled) != 1 || !errors.As(handled[0], &fieldErr) || fieldErr.Field != "Any" ||
+		fieldErr.Path != "div > ol > li" || fieldErr.Attr != "v-for" {
+		t.Fatalf("expected field error with location, got %v", handled)
+	}
+
+	vm.Set("Missing", true)
+	vm.Set("Message", 1)
+	if len(handled) != 3 {
+		t.Errorf("expected errors for invalid sets, got %v", handled)
+	}
+
+	// Errors of subcomponents propagate to the parent handler, the parent still renders.
+	handled = nil
+	sub := newTestComp(t, Props("Todo"), Template(`<p v-if="Todo.Seen"></p>`))
+	_, doc = newTestVM(t, `<div><p-sub></p-sub><p>{{ Message }}</p></div>`,
+		Data(&testData{Message: "a"}), Sub("p-sub", sub), handler)
+	if len(handled) != 1 {
+		t.Errorf("expected subcomponent error to be handled by parent, got %v", handled)
+	}
+	assertHTML(t, doc, "<div><p></p><p>a</p></div>")
+}